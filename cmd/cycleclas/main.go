@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pjvm742/quantum-cycles/cycles"
+)
+
+func main() {
+	args := os.Args
+	nargs := len(args)
+	var filename string
+	var adis bool
+	var vdis bool
+	var external bool
+	for a := 1; a < nargs; a++ {
+		if args[a] == "-l" {
+			/* a maximum chain length is not a flow constraint */
+			fmt.Fprintln(os.Stderr, "Malformed arguments: the flow formulation cannot limit the chains; use cyclequbo -l")
+			os.Exit(1)
+		} else if args[a] == "-e" {
+			external = true
+		} else if args[a] == "-a" {
+			adis = true
+		} else if args[a] == "-v" {
+			vdis = true
+			adis = true
+		} else {
+			filename = args[a]
+		}
+	}
+	graph := cycles.Addchainarcs(readgraph(filename))
+	if adis {
+		cycles.Setcaps1(graph)
+	}
+	reducedg, oldinds := cycles.Simplify(graph)
+	network := reducedg
+	if vdis {
+		network = cycles.Vdis2adis(reducedg)
+	}
+	fmt.Printf("After pre-processing, the number of vertices is %d\n", network.N)
+	var flow cycles.Flow
+	if external {
+		outputfile := strings.Replace(filename, ".graph.tsv", ".graph.dimacs", 1)
+		writedimacs(outputfile, network)
+		fmt.Fprintln(os.Stderr, "Please press enter when the solution file is there")
+		fmt.Scanf("\n")
+		solfile := strings.Replace(filename, ".graph.tsv", ".sol.dimacs", 1)
+		flow = readdimacs(solfile, network)
+	} else {
+		flow = cycles.Maxcirculation(network)
+	}
+	if vdis {
+		flow = cycles.Adis2vdis(reducedg, flow)
+	}
+	if !cycles.Iscirculation(reducedg, flow) {
+		fmt.Fprintln(os.Stderr, "The solution is invalid")
+		os.Exit(1)
+	}
+	ndds := cycles.Nddlist(reducedg.Ndd, oldinds)
+	if len(ndds) > 0 {
+		fmt.Fprintln(os.Stderr, "The chains are not limited in length")
+	}
+	fmt.Printf("Solution value: %d\n", cycles.Solval(reducedg, flow))
+	decomp := cycles.Decomp(reducedg, flow, oldinds)
+	cycles.Printcycles(os.Stdout, cycles.Markchains(decomp, ndds))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func readgraph(filename string) cycles.Cwdgraph {
+	f, err := os.Open(filename)
+	if err != nil {
+		fail(err)
+	}
+	defer f.Close()
+	g, err := cycles.Readgraph(f)
+	if err != nil {
+		fail(err)
+	}
+	return g
+}
+
+func writedimacs(filename string, g cycles.Cwdgraph) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	if err := cycles.Writedimacs(f, g); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
+
+func readdimacs(filename string, g cycles.Cwdgraph) cycles.Flow {
+	f, err := os.Open(filename)
+	if err != nil {
+		fail(err)
+	}
+	defer f.Close()
+	flow, err := cycles.Readdimacs(f, g)
+	if err != nil {
+		fail(err)
+	}
+	return flow
+}
@@ -0,0 +1,673 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pjvm742/quantum-cycles/cycles"
+)
+
+/* Usage:
+ *   cyclequbo [options] X.graph.tsv
+ *     writes X.qubo.tsv, samples it and decodes the samples;
+ *     without -S, waits until X.sol.tsv has been made by hand
+ *   cyclequbo encode [options] X.graph.tsv
+ *     writes X.qubo.tsv and the sidecar X.enc.json
+ *   cyclequbo decode X.enc.json [samples.tsv]
+ *     decodes the samples, by default X.sol.tsv
+ *   cyclequbo decode X.part0.enc.json [samples.tsv] X.part1.enc.json ...
+ *     decodes the parts that encode -d wrote, each like the above,
+ *     and puts their solutions together
+ *
+ * The sampler given with -S is either "anneal", "tabu" or "temper",
+ * for the built-in simulated annealing, tabu search
+ * or parallel tempering, or a command that works like the sendrecv scripts,
+ * for example -S "python sendrecv-hybrid.py";
+ * -T sets a timeout for it in seconds; tabu search stops in time
+ * and returns what it has found so far.
+ * With -k, no cycle gets longer than the given number of arcs;
+ * -C then uses one variable per cycle, which needs -a or -v
+ * and cannot be used with -e unary or -e onehot.
+ * Chains from non-directed donors get at most the number
+ * of transplants given with -l.
+ * With -n, which needs -a or -v and cannot be used with -k, -l or -C,
+ * there are no variables for the flow through the vertices.
+ * With -d, every strongly connected component of the simplified graph
+ * is encoded, sampled and decoded on its own, as X.partP.graph.tsv
+ * would be, and the best solutions of the parts are put together.
+ * With -f, the arcs are also capped by the flow that can return
+ * along the other arcs, and the variables this saves are reported.
+ * With -x, all assignments of the QUBO are tried instead of sampling it,
+ * which is only feasible for small ones; the lowest feasible one
+ * is compared with the optimum of the flow formulation.
+ * With -e unary or -e onehot, the flows are spread over
+ * one variable per unit or per value instead of over bits.
+ * With -s, the QUBO is written (and sent to the sampler)
+ * as a sparse list of terms instead of a full symmetric matrix;
+ * with -u, as a full matrix with the terms in the upper triangle.
+ * With -I, the QUBO is also written in spin form to X.ising.tsv;
+ * samples may have spins, -1 and 1, instead of 0 and 1.
+ * With -p, the penalties are relative to a bound that makes sure
+ * the lowest energy is that of a feasible solution,
+ * instead of to the adjusted average of the weights.
+ * With -b, that bound is also found without -p, to show
+ * how far the smallest penalty falls below it.
+ * With -t, the penalty multiplier is searched for, starting from
+ * the one given with -m or -M, until the given fraction of the reads
+ * is feasible; this needs -S, and the steps are written to X.tune.tsv. */
+func main() {
+	args := os.Args
+	mode := ""
+	if len(args) > 1 && (args[1] == "encode" || args[1] == "decode") {
+		mode = args[1]
+		args = args[1:]
+	}
+	if mode == "decode" {
+		decode(args)
+		return
+	}
+
+	nargs := len(args)
+	var filename string
+	opts := cycles.Encopts{Mult: 1, Ringf: 1}
+	var samplername string
+	var timeout float64
+	format := cycles.Symmetric
+	var target float64
+	var ising bool
+	var split bool
+	var tighten bool
+	var exhaust bool
+	var checkbound bool
+	var expect string
+	for a := 1; a < nargs; a++ {
+		var err error
+		switch expect {
+		case "-m", "-M":
+			_, err = fmt.Sscanf(args[a], "%f", &opts.Mult)
+		case "-r":
+			_, err = fmt.Sscanf(args[a], "%f", &opts.Ringf)
+		case "-T":
+			_, err = fmt.Sscanf(args[a], "%f", &timeout)
+		case "-k":
+			_, err = fmt.Sscanf(args[a], "%d", &opts.Maxlen)
+		case "-l":
+			_, err = fmt.Sscanf(args[a], "%d", &opts.Chainlen)
+		case "-t":
+			_, err = fmt.Sscanf(args[a], "%f", &target)
+		case "-S":
+			samplername = args[a]
+		case "-e":
+			if args[a] == "binary" {
+				opts.Intenc = cycles.Binary
+			} else if args[a] == "unary" {
+				opts.Intenc = cycles.Unary
+			} else if args[a] == "onehot" {
+				opts.Intenc = cycles.Onehot
+			} else {
+				fmt.Fprintln(os.Stderr, "Malformed arguments: -e takes binary, unary or onehot")
+				os.Exit(1)
+			}
+		case "":
+			if args[a] == "-a" {
+				opts.Adis = true
+			} else if args[a] == "-v" {
+				opts.Vdis = true
+				opts.Adis = true
+			} else if args[a] == "-C" {
+				opts.Cycleform = true
+			} else if args[a] == "-s" {
+				format = cycles.Sparse
+			} else if args[a] == "-u" {
+				format = cycles.Upper
+			} else if args[a] == "-I" {
+				ising = true
+			} else if args[a] == "-p" {
+				opts.Safe = true
+			} else if args[a] == "-b" {
+				checkbound = true
+			} else if args[a] == "-n" {
+				opts.Noslack = true
+			} else if args[a] == "-d" {
+				split = true
+			} else if args[a] == "-f" {
+				tighten = true
+			} else if args[a] == "-x" {
+				exhaust = true
+			} else if args[a] == "-m" || args[a] == "-r" || args[a] == "-S" || args[a] == "-T" || args[a] == "-k" || args[a] == "-l" || args[a] == "-t" || args[a] == "-e" {
+				expect = args[a]
+			} else if args[a] == "-M" {
+				opts.Absmult = true
+				expect = args[a]
+			} else {
+				filename = args[a]
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Malformed arguments: argument after %s isn't a number\n", expect)
+			os.Exit(1)
+		}
+		expect = ""
+	}
+	if expect != "" {
+		fmt.Fprintf(os.Stderr, "Malformed arguments: %s needs an argument\n", expect)
+		os.Exit(1)
+	}
+
+	if opts.Cycleform && (opts.Maxlen == 0 || !opts.Adis) {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: -C needs -k and either -a or -v")
+		os.Exit(1)
+	}
+	if opts.Cycleform && opts.Intenc != cycles.Binary {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: -C cannot be used with -e unary or -e onehot")
+		os.Exit(1)
+	}
+	if opts.Noslack && (!opts.Adis || opts.Maxlen > 0 || opts.Chainlen > 0 || opts.Cycleform) {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: -n needs -a or -v, and cannot be used with -k, -l or -C")
+		os.Exit(1)
+	}
+	if exhaust && (samplername != "" || target > 0 || mode == "encode") {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: -x cannot be used with -S, -t or encode")
+		os.Exit(1)
+	}
+	if opts.Safe && opts.Absmult {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: -p cannot be used with -M")
+		os.Exit(1)
+	}
+	if target > 0 && (samplername == "" || mode == "encode") {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: -t needs -S, and cannot be used with encode")
+		os.Exit(1)
+	}
+	run := runopts{mode, samplername, time.Duration(timeout * float64(time.Second)), format, target, ising, exhaust, checkbound}
+
+	graph := cycles.Addchainarcs(readgraph(filename))
+	var reducedg cycles.Cwdgraph
+	var oldinds []int
+	if tighten {
+		plaing, plaininds := cycles.Simplify(graph.Copy())
+		reducedg, oldinds = cycles.Simplifyflow(graph)
+		nvar := cycles.Countvars(reducedg, oldinds, opts)
+		fmt.Fprintf(os.Stderr, "Capping the arcs by maximum flows leaves %d variables, %d fewer than without\n", nvar, cycles.Countvars(plaing, plaininds, opts) - nvar)
+	} else {
+		reducedg, oldinds = cycles.Simplify(graph)
+	}
+	fmt.Printf("After pre-processing, the number of vertices is %d\n", reducedg.N)
+	if !split {
+		if value, cycs, ok := solve(filename, reducedg, oldinds, opts, run); ok {
+			printsolution(value, cycs)
+		}
+		return
+	}
+
+	parts, indices := cycles.Split(reducedg)
+	fmt.Fprintf(os.Stderr, "The graph splits into %d parts\n", len(parts))
+	total := 0
+	var all []cycles.Cycle
+	for p, part := range parts {
+		partinds := make([]int, part.N)
+		for q, v := range indices[p] {
+			partinds[q] = oldinds[v]
+		}
+		fmt.Fprintf(os.Stderr, "Part %d, with %d vertices:\n", p, part.N)
+		partfile := strings.Replace(filename, ".graph.tsv", fmt.Sprintf(".part%d.graph.tsv", p), 1)
+		value, cycs, ok := solve(partfile, part, partinds, opts, run)
+		total += value
+		all = append(all, cycs...)
+		if ok {
+			fmt.Fprintf(os.Stderr, "Value of part %d: %d\n", p, value)
+		}
+	}
+	if mode != "encode" {
+		printsolution(total, all)
+	}
+}
+
+/* how a graph is sampled, next to how it is encoded */
+type runopts struct {
+	mode string
+	samplername string
+	timelimit time.Duration
+	format cycles.Quboformat
+	target float64
+	ising bool
+	/* try all assignments instead of sampling */
+	exhaust bool
+	/* compare the penalties with the safe bound */
+	checkbound bool
+}
+
+/* Encodes the simplified graph g, samples it and decodes the samples;
+ * filename only determines the names of the files that are written.
+ * Returns the best solution found, in terms of the original graph,
+ * and false if there is none because only encoding was asked for. */
+func solve(filename string, g cycles.Cwdgraph, oldinds []int, opts cycles.Encopts, run runopts) (int, []cycles.Cycle, bool) {
+	var qubomatrix cycles.Qubo
+	var enc cycles.Encoding
+	var samples []cycles.Sample
+	if run.target > 0 {
+		sampler := mksampler(run.samplername, run.timelimit, run.format)
+		topts := cycles.Tuneopts{Target: run.target, Steps: 12, Precision: 0.05}
+		tuned, err := cycles.Tune(g, oldinds, opts, sampler, topts, os.Stderr)
+		writetrajectory(strings.Replace(filename, ".graph.tsv", ".tune.tsv", 1), tuned.Steps)
+		if err != nil {
+			fail(err)
+		}
+		qubomatrix, enc, samples = tuned.Problem, tuned.Enc, tuned.Samples
+		fmt.Fprintf(os.Stderr, "Chosen multiplier: %.4g\n", enc.Opts.Mult)
+	} else {
+		qubomatrix, enc = cycles.Encode(g, oldinds, opts)
+	}
+	if !opts.Absmult && !opts.Safe {
+		fmt.Fprintf(os.Stderr, "Adjusted average  of the weights (scale for the penalties): %.2g\n", enc.Avg)
+	} else if !opts.Absmult {
+		fmt.Fprintf(os.Stderr, "Adjusted average  of the weights (not used for the penalties): %.2g\n", enc.Avg)
+	}
+	if run.checkbound && !opts.Safe {
+		enc.Bound = enc.Safebound()
+	}
+	if enc.Bound > 0 {
+		fmt.Fprintf(os.Stderr, "Smallest penalty: %.4g; safe bound: %.4g; ratio: %.3g\n", enc.Minpen, enc.Bound, enc.Minpen / enc.Bound)
+	} else {
+		fmt.Fprintf(os.Stderr, "Smallest penalty: %.4g\n", enc.Minpen)
+	}
+	outputfile := strings.Replace(filename, ".graph.tsv", ".qubo.tsv", 1)
+	writeQUBO(outputfile, qubomatrix, run.format)
+	if run.ising {
+		writeising(strings.Replace(filename, ".graph.tsv", ".ising.tsv", 1), cycles.Toising(qubomatrix))
+	}
+	if run.mode == "encode" {
+		encfile := strings.Replace(filename, ".graph.tsv", ".enc.json", 1)
+		writeencoding(encfile, enc)
+		return 0, nil, false
+	}
+	if run.exhaust {
+		value, cycs := exhaustive(qubomatrix, enc)
+		return value, cycs, true
+	}
+	solfile := strings.Replace(filename, ".graph.tsv", ".sol.tsv", 1)
+	if samples != nil {
+		writesamples(solfile, samples)
+	} else if run.samplername == "" {
+		fmt.Fprintf(os.Stderr, "Please press enter when %s is there\n", solfile)
+		fmt.Scanf("\n")
+		samples = readsamples(solfile, enc.Nvar())
+	} else {
+		sampler := mksampler(run.samplername, run.timelimit, run.format)
+		var err error
+		samples, err = sampler.Sample(qubomatrix)
+		if err != nil {
+			fail(err)
+		}
+		writesamples(solfile, samples)
+	}
+	partsfile := strings.Replace(filename, ".graph.tsv", ".parts.tsv", 1)
+	value, cycs := processsolutions(samples, enc, &qubomatrix, partsfile)
+	return value, cycs, true
+}
+
+func printsolution(value int, cycs []cycles.Cycle) {
+	fmt.Printf("Solution value: %d\n", value)
+	cycles.Printcycles(os.Stdout, cycs)
+}
+
+func mksampler(name string, timeout time.Duration, format cycles.Quboformat) cycles.Sampler {
+	if name == "anneal" {
+		return cycles.Annealer{Opts: cycles.Annealopts{
+			Reads: 1024,
+			Sweeps: 1000,
+			Schedule: cycles.Geometric,
+			Seed: time.Now().UnixNano(),
+		}}
+	}
+	if name == "tabu" {
+		return cycles.Tabusearch{Opts: cycles.Tabuopts{
+			Reads: 64,
+			Timeout: timeout,
+			Seed: time.Now().UnixNano(),
+		}}
+	}
+	if name == "temper" {
+		return cycles.Tempering{Opts: cycles.Temperopts{
+			Reads: 128,
+			Replicas: 16,
+			Sweeps: 10,
+			Rounds: 50,
+			Seed: time.Now().UnixNano(),
+		}}
+	}
+	return cycles.External{
+		Command: strings.Fields(name),
+		Timeout: timeout,
+		Stderr: os.Stderr,
+		Format: format,
+	}
+}
+
+func decode(args []string) {
+	/* every sidecar, with the samples file after it if given */
+	var encfiles []string
+	var solfiles []string
+	given := false
+	for a := 1; a < len(args); a++ {
+		if a == 1 || strings.HasSuffix(args[a], ".enc.json") {
+			encfiles = append(encfiles, args[a])
+			solfiles = append(solfiles, strings.Replace(args[a], ".enc.json", ".sol.tsv", 1))
+			given = false
+		} else if !given {
+			solfiles[len(solfiles)-1] = args[a]
+			given = true
+		} else {
+			encfiles = nil
+			break
+		}
+	}
+	if len(encfiles) == 0 {
+		fmt.Fprintln(os.Stderr, "Malformed arguments: decode needs the sidecar files, each optionally followed by its sample file")
+		os.Exit(1)
+	}
+	if len(encfiles) == 1 {
+		printsolution(decodepart(encfiles[0], solfiles[0]))
+		return
+	}
+
+	/* the parts that encode -d wrote, put together like solve does */
+	total := 0
+	var all []cycles.Cycle
+	for p := range encfiles {
+		fmt.Fprintf(os.Stderr, "Part %d, from %s:\n", p, encfiles[p])
+		value, cycs := decodepart(encfiles[p], solfiles[p])
+		total += value
+		all = append(all, cycs...)
+		fmt.Fprintf(os.Stderr, "Value of part %d: %d\n", p, value)
+	}
+	printsolution(total, all)
+}
+
+/* decodes the samples in solfile with the sidecar encfile */
+func decodepart(encfile string, solfile string) (int, []cycles.Cycle) {
+	f, err := os.Open(encfile)
+	if err != nil {
+		fail(err)
+	}
+	enc, err := cycles.Readencoding(f)
+	f.Close()
+	if err != nil {
+		fail(err)
+	}
+	samples := readsamples(solfile, enc.Nvar())
+	/* the QUBO is only needed to check the energies */
+	qubofile := strings.Replace(encfile, ".enc.json", ".qubo.tsv", 1)
+	var problem *cycles.Qubo
+	if q, err := readQUBO(qubofile); err != nil {
+		fmt.Fprintf(os.Stderr, "Not checking the energies: %v\n", err)
+	} else if q.N != enc.Nvar() {
+		fmt.Fprintf(os.Stderr, "Not checking the energies: %s has %d variables, the sidecar %d\n", qubofile, q.N, enc.Nvar())
+	} else {
+		/* a full matrix has the offset only in a comment */
+		q.Offset = enc.Offset
+		problem = &q
+	}
+	partsfile := strings.TrimSuffix(solfile, ".sol.tsv") + ".parts.tsv"
+	return processsolutions(samples, enc, problem, partsfile)
+}
+
+/* the number of runner-up solutions,
+ * and of samples with a wrong energy, that are reported */
+const nrunnerups = 5
+const nmismatches = 5
+
+/* Reports on the samples and returns the best solution among them,
+ * or among their repairs if none is feasible.
+ * problem may be nil, then the energies are not checked
+ * and not split into their parts, which otherwise go to partsfile */
+func processsolutions(samples []cycles.Sample, enc cycles.Encoding, problem *cycles.Qubo, partsfile string) (int, []cycles.Cycle) {
+	if len(samples) == 0 {
+		fmt.Fprintln(os.Stderr, "The solution file is empty")
+		os.Exit(1)
+	}
+	if problem != nil {
+		mismatches, shifted := cycles.Checkenergies(*problem, samples)
+		if shifted > 0 {
+			fmt.Fprintf(os.Stderr, "The reported energy of %d samples leaves out the offset of the Ising model\n", shifted)
+		}
+		if len(mismatches) > 0 {
+			fmt.Fprintf(os.Stderr, "The reported energy of %d samples does not match the QUBO; are the variables in the right order?\n", len(mismatches))
+		}
+		for m, s := range mismatches {
+			if m == nmismatches {
+				break
+			}
+			if len(samples[s].X) != problem.N {
+				fmt.Fprintf(os.Stderr, "Sample %d: %d variables instead of %d\n", s, len(samples[s].X), problem.N)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Sample %d: reported %.6g, computed %.6g\n", s, samples[s].Energy, cycles.Energy(*problem, samples[s].X))
+		}
+		writeparts(partsfile, samples, enc, *problem)
+	}
+	/* from here on, the energies include the offset */
+	for s := range samples {
+		samples[s].Energy += enc.Offset
+	}
+	solutions, stats := enc.Solutions(samples)
+	if !math.IsNaN(stats.Meanenergy) {
+		fmt.Fprintf(os.Stderr, "Mean energy of the reads: %.6g; the lowest, %.6g, is reached in %d reads\n", stats.Meanenergy, stats.Minenergy, stats.Atminenergy)
+	}
+	if len(solutions) == 0 {
+		fmt.Fprintln(os.Stderr, "None of the solutions are feasible")
+		fmt.Fprintln(os.Stderr, "Breaks in the first solution:")
+		enc.Showbreaks(os.Stderr, samples[0].X)
+		return repairsolutions(samples, enc)
+	}
+	fmt.Fprintf(os.Stderr, "%d of the %d reads are feasible (%.1f%%), giving %d distinct solutions\n", stats.Feasible, stats.Reads, 100 * float64(stats.Feasible) / float64(stats.Reads), len(solutions))
+	fmt.Fprintf(os.Stderr, "Mean value of the feasible reads: %.2f\n", stats.Meanvalue)
+	best := solutions[0]
+	fmt.Fprintf(os.Stderr, "The %d-th solution is the best feasible one\n", best.Index)
+	if problem != nil {
+		parts := enc.Parts(*problem, [][]bool{best.X})[0]
+		fmt.Fprintf(os.Stderr, "Its energy: %.6g, of which objective %.6g and penalty %.6g\n", parts.Energy, parts.Objective, parts.Penalty)
+	}
+	for r := 1; r < len(solutions) && r <= nrunnerups; r++ {
+		sol := solutions[r]
+		fmt.Fprintf(os.Stderr, "Runner-up: value %d, energy %.6g, in %d reads (the %d-th solution)\n", sol.Value, sol.Energy, sol.Occurrences, sol.Index)
+	}
+	return best.Value, enc.Decomp(best.X)
+}
+
+/* the most variables -x takes on */
+const maxexhaust = 36
+
+/* Tries all assignments and reports whether the ground state
+ * is feasible and as good as the classical optimum.
+ * Returns the feasible assignment with the lowest energy. */
+func exhaustive(problem cycles.Qubo, enc cycles.Encoding) (int, []cycles.Cycle) {
+	if problem.N > maxexhaust {
+		fmt.Fprintf(os.Stderr, "Too many variables to try all assignments: %d, at most %d\n", problem.N, maxexhaust)
+		os.Exit(1)
+	}
+	res := cycles.Exhaust(problem, enc.Feasible)
+	nfeasible := 0
+	for _, x := range res.Ground {
+		if enc.Feasible(x) {
+			nfeasible++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Lowest energy of the 2^%d assignments: %.6g, reached by %d of them\n", problem.N, res.Energy, res.Nground)
+	if res.Nground > len(res.Ground) {
+		fmt.Fprintf(os.Stderr, "Of the first %d of those, %d are feasible\n", len(res.Ground), nfeasible)
+	} else {
+		fmt.Fprintf(os.Stderr, "Of those, %d are feasible\n", nfeasible)
+	}
+	if res.Feasible == nil {
+		fmt.Fprintln(os.Stderr, "None of the assignments are feasible")
+		os.Exit(1)
+	}
+	value := enc.Value(res.Feasible)
+	fmt.Fprintf(os.Stderr, "Lowest energy of a feasible assignment: %.6g, with value %d\n", res.Feasenergy, value)
+	if nfeasible == 0 {
+		fmt.Fprintln(os.Stderr, "The penalties are too low: no ground state is feasible")
+	}
+	if opt, ok := enc.Optimum(); ok {
+		if value == opt {
+			fmt.Fprintf(os.Stderr, "This is the optimum of the flow formulation, %d\n", opt)
+		} else {
+			fmt.Fprintf(os.Stderr, "The optimum of the flow formulation is %d, %d more\n", opt, opt - value)
+		}
+	}
+	return value, enc.Decomp(res.Feasible)
+}
+
+/* repairs every sample and returns the best result */
+func repairsolutions(samples []cycles.Sample, enc cycles.Encoding) (int, []cycles.Cycle) {
+	repairer := enc.Repairer()
+	var best cycles.Repair
+	bestindex := -1
+	for s, sample := range samples {
+		rep := repairer.Repair(sample.X)
+		if bestindex < 0 || rep.Value > best.Value {
+			best = rep
+			bestindex = s
+		}
+	}
+	fmt.Fprintf(os.Stderr, "The repair of the %d-th solution is the best\n", bestindex)
+	fmt.Fprintf(os.Stderr, "Value of its arcs as sampled: %d\n", best.Raw)
+	fmt.Fprintf(os.Stderr, "After dropping the unbalanced flow: %d\n", best.Kept)
+	fmt.Fprintf(os.Stderr, "After adding short cycles: %d\n", best.Added)
+	fmt.Fprintf(os.Stderr, "After local improvement: %d (%+d compared with the sample)\n", best.Value, best.Value - best.Raw)
+	return best.Value, enc.Repairdecomp(best)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func readgraph(filename string) cycles.Cwdgraph {
+	f, err := os.Open(filename)
+	if err != nil {
+		fail(err)
+	}
+	defer f.Close()
+	g, err := cycles.Readgraph(f)
+	if err != nil {
+		fail(err)
+	}
+	return g
+}
+
+func writeQUBO(filename string, problem cycles.Qubo, format cycles.Quboformat) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	if err := cycles.Writequboas(f, problem, format); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
+
+func readQUBO(filename string) (cycles.Qubo, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return cycles.Qubo{}, err
+	}
+	defer f.Close()
+	return cycles.Readqubo(f)
+}
+
+/* writes the energy, objective and penalty of every sample */
+func writeparts(filename string, samples []cycles.Sample, enc cycles.Encoding, problem cycles.Qubo) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	sols := make([][]bool, len(samples))
+	for s := range samples {
+		sols[s] = samples[s].X
+	}
+	for _, parts := range enc.Parts(problem, sols) {
+		if _, err := fmt.Fprintf(f, "%s\t%s\t%s\n", ftoa(parts.Energy), ftoa(parts.Objective), ftoa(parts.Penalty)); err != nil {
+			fail(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
+
+/* a float with as many digits as it takes to read it back exactly */
+func ftoa(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func writeising(filename string, model cycles.Ising) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	if err := cycles.Writeising(f, model); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
+
+func writetrajectory(filename string, steps []cycles.Tunestep) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	if err := cycles.Writetrajectory(f, steps); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
+
+func writeencoding(filename string, enc cycles.Encoding) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	if err := cycles.Writeencoding(f, enc); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
+
+func readsamples(filename string, nvar int) []cycles.Sample {
+	f, err := os.Open(filename)
+	if err != nil {
+		fail(err)
+	}
+	defer f.Close()
+	samples, err := cycles.Readsamples(f, nvar)
+	if err != nil {
+		fail(err)
+	}
+	return samples
+}
+
+func writesamples(filename string, samples []cycles.Sample) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fail(err)
+	}
+	if err := cycles.Writesamples(f, samples); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(err)
+	}
+}
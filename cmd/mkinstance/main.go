@@ -0,0 +1,154 @@
+package main
+
+import (
+	"math/rand"
+	"math"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pjvm742/quantum-cycles/cycles"
+)
+
+func mkweights(g cycles.Cwdgraph) {
+	for k := range g.Arcs {
+		g.Arcs[k].Wgt = randwgt()
+	}
+}
+
+func randwgt() int {
+	options := [6]int{2,2,3,4,6,11}
+	i := rand.Intn(6)
+	return options[i]
+}
+
+func mkarcs(n int) cycles.Cwdgraph {
+	caps := make(map[[2]int]int)
+
+	caps[[2]int{1, 0}] = randcap()
+	caps[[2]int{2, 0}] = randcap()
+
+	m := int(math.Sqrt(float64(n)))
+	for i := 0; i < 3; i++ {
+		for k := 0; k < m; k++ {
+			j := rand.Intn(n)
+			if i != j {
+				caps[[2]int{i, j}] = randcap()
+			}
+		}
+	}
+
+	for i := 3; i < n; i++ {
+		for k := 0; k < 3; k++ {
+			j := rand.Intn(n)
+			if i != j {
+				caps[[2]int{i, j}] = randcap()
+			}
+		}
+		j := rand.Intn(3)
+		caps[[2]int{i, j}] = randcap()
+	}
+
+	var arcs []cycles.Arc
+	for ij, c := range caps {
+		arcs = append(arcs, cycles.Arc{Start: ij[0], End: ij[1], Cpty: c})
+	}
+	sort.Slice(arcs, func(p, q int) bool {
+		if arcs[p].Start != arcs[q].Start {
+			return arcs[p].Start < arcs[q].Start
+		}
+		return arcs[p].End < arcs[q].End
+	})
+	return cycles.Newgraph(n, arcs, nil)
+}
+
+/* turns k vertices other than the first three
+ * into non-directed donors, which lose their incoming arcs */
+func mkndds(g cycles.Cwdgraph, k int) cycles.Cwdgraph {
+	n := g.N
+	ndd := make([]bool, n)
+	for _, p := range rand.Perm(n - 3)[:k] {
+		ndd[p + 3] = true
+	}
+	var arcs []cycles.Arc
+	for _, arc := range g.Arcs {
+		if !ndd[arc.End] {
+			arcs = append(arcs, arc)
+		}
+	}
+	return cycles.Newgraph(n, arcs, ndd)
+}
+
+func randcap() int {
+	options := [6]int{1,1,2,3,4,6}
+	i := rand.Intn(6)
+	return options[i]
+}
+
+func main() {
+	args := os.Args
+	nargs := len(args)
+	var size int = 30
+	var cui bool
+	var ndds bool
+	var sparse bool
+	var nndd int
+	var expectnndd bool
+	for a := 1; a < nargs; a++ {
+		if expectnndd {
+			_, err := fmt.Sscanf(args[a], "%d", &nndd)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Malformed arguments: argument after -n isn't an integer")
+				os.Exit(1)
+			}
+			expectnndd = false
+		} else if args[a] == "-c" {
+			cui = true
+		} else if args[a] == "-d" {
+			ndds = true
+		} else if args[a] == "-s" {
+			sparse = true
+		} else if args[a] == "-n" {
+			expectnndd = true
+		} else {
+			_, err := fmt.Sscanf(args[a], "%d", &size)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Malformed arguments: remaining argument isn't an integer")
+				os.Exit(1)
+			}
+			if size < 4 {
+				fmt.Fprintln(os.Stderr, "Requested instance size is too small, need at least 4")
+				os.Exit(1)
+			}
+		}
+	}
+	if nndd > size - 3 {
+		fmt.Fprintln(os.Stderr, "Too many non-directed donors requested for the instance size")
+		os.Exit(1)
+	}
+	var graph cycles.Cwdgraph
+	if cui {
+		var err error
+		graph, err = cycles.Readcui(os.Stdin, ndds)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		graph = mkarcs(size)
+		if nndd > 0 {
+			graph = mkndds(graph, nndd)
+		}
+	}
+	mkweights(graph)
+	var err error
+	if sparse {
+		err = cycles.Writearcs(os.Stdout, graph)
+	} else {
+		err = cycles.Writegraph(os.Stdout, graph)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,129 @@
+/* Samples a QUBO without leaving Go:
+ * like the sendrecv scripts, it reads the QUBO, in either format,
+ * from standard input and writes the aggregated samples
+ * to standard output.
+ * By default by simulated annealing; with -tabu, by tabu search,
+ * for which -tenure, -i (iterations without improvement)
+ * and -T (time limit in seconds) can be given;
+ * with -pt, by parallel tempering, with -r replicas
+ * between -b and -B, which do -s sweeps between -rounds swaps. */
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pjvm742/quantum-cycles/cycles"
+)
+
+func main() {
+	args := os.Args
+	nargs := len(args)
+	opts := cycles.Annealopts{
+		Reads: 1024,
+		Sweeps: 1000,
+		Schedule: cycles.Geometric,
+		Seed: time.Now().UnixNano(),
+	}
+	tabu := cycles.Tabuopts{Reads: 64}
+	var usetabu bool
+	temper := cycles.Temperopts{Reads: 128, Replicas: 16, Sweeps: 10, Rounds: 50}
+	var usetemper bool
+	var sweeps int
+	var reads int
+	var timeout float64
+	var expect string
+	for a := 1; a < nargs; a++ {
+		var err error
+		switch expect {
+		case "-n":
+			_, err = fmt.Sscanf(args[a], "%d", &reads)
+		case "-s":
+			_, err = fmt.Sscanf(args[a], "%d", &sweeps)
+		case "-b":
+			_, err = fmt.Sscanf(args[a], "%g", &opts.Betamin)
+		case "-B":
+			_, err = fmt.Sscanf(args[a], "%g", &opts.Betamax)
+		case "-seed":
+			_, err = fmt.Sscanf(args[a], "%d", &opts.Seed)
+		case "-tenure":
+			_, err = fmt.Sscanf(args[a], "%d", &tabu.Tenure)
+		case "-i":
+			_, err = fmt.Sscanf(args[a], "%d", &tabu.Iterations)
+		case "-T":
+			_, err = fmt.Sscanf(args[a], "%g", &timeout)
+		case "-r":
+			_, err = fmt.Sscanf(args[a], "%d", &temper.Replicas)
+		case "-rounds":
+			_, err = fmt.Sscanf(args[a], "%d", &temper.Rounds)
+		case "":
+			if args[a] == "-tabu" {
+				usetabu = true
+			} else if args[a] == "-pt" {
+				usetemper = true
+			} else if args[a] == "-l" {
+				opts.Schedule = cycles.Linear
+			} else if args[a] == "-g" {
+				opts.Schedule = cycles.Geometric
+			} else if args[a] == "-n" || args[a] == "-s" || args[a] == "-b" || args[a] == "-B" || args[a] == "-seed" || args[a] == "-tenure" || args[a] == "-i" || args[a] == "-T" || args[a] == "-r" || args[a] == "-rounds" {
+				expect = args[a]
+				continue
+			} else if len(args[a]) > 0 && args[a][0] == '-' {
+				fmt.Fprintf(os.Stderr, "Malformed arguments: unknown option %s\n", args[a])
+				os.Exit(1)
+			} else {
+				fmt.Fprintf(os.Stderr, "Malformed arguments: unexpected argument %q\n", args[a])
+				os.Exit(1)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Malformed arguments: argument after %s isn't a number\n", expect)
+			os.Exit(1)
+		}
+		expect = ""
+	}
+	if expect != "" {
+		fmt.Fprintf(os.Stderr, "Malformed arguments: %s needs an argument\n", expect)
+		os.Exit(1)
+	}
+	if reads != 0 {
+		opts.Reads = reads
+		tabu.Reads = reads
+		temper.Reads = reads
+	}
+	if sweeps != 0 {
+		opts.Sweeps = sweeps
+		temper.Sweeps = sweeps
+	}
+	tabu.Seed = opts.Seed
+	tabu.Timeout = time.Duration(timeout * float64(time.Second))
+	temper.Seed = opts.Seed
+	temper.Betamin, temper.Betamax = opts.Betamin, opts.Betamax
+	if opts.Reads < 1 || tabu.Reads < 1 || temper.Reads < 1 || opts.Sweeps < 1 || temper.Sweeps < 1 {
+		fmt.Fprintln(os.Stderr, "The number of reads and of sweeps must be positive")
+		os.Exit(1)
+	}
+
+	problem, err := cycles.Readqubo(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var samples []cycles.Sample
+	if usetabu {
+		samples = cycles.Aggregate(cycles.Tabu(problem, tabu))
+	} else if usetemper {
+		if temper.Replicas < 1 || temper.Rounds < 1 {
+			fmt.Fprintln(os.Stderr, "The number of replicas and of rounds must be positive")
+			os.Exit(1)
+		}
+		samples = cycles.Aggregate(cycles.Temper(problem, temper))
+	} else {
+		samples = cycles.Aggregate(cycles.Anneal(problem, opts))
+	}
+	if err := cycles.Writesamples(os.Stdout, samples); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
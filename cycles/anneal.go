@@ -0,0 +1,151 @@
+package cycles
+
+import (
+	"math"
+	"math/rand"
+)
+
+type Schedule int
+
+const (
+	Geometric Schedule = iota
+	Linear
+)
+
+/* Settings for simulated annealing.
+ * A zero Betamin or Betamax is replaced by a default
+ * derived from the coefficients of the QUBO, like neal does. */
+type Annealopts struct {
+	Reads int
+	Sweeps int
+	Betamin float64
+	Betamax float64
+	Schedule Schedule
+	Seed int64
+}
+
+/* Samples the QUBO by simulated annealing with single-variable
+ * Metropolis updates; returns one sample per read, not aggregated. */
+func Anneal(problem Qubo, opts Annealopts) []Sample {
+	n := problem.N
+	diag, adj := problem.adjacency()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	hot, cold := betarange(diag, adj)
+	if opts.Betamin > 0 {
+		hot = opts.Betamin
+	}
+	if opts.Betamax > 0 {
+		cold = opts.Betamax
+	}
+	betas := make([]float64, opts.Sweeps)
+	for s := 0; s < opts.Sweeps; s++ {
+		t := float64(0)
+		if opts.Sweeps > 1 {
+			t = float64(s) / float64(opts.Sweeps - 1)
+		}
+		if opts.Schedule == Linear {
+			betas[s] = hot + t*(cold - hot)
+		} else {
+			betas[s] = hot * math.Pow(cold/hot, t)
+		}
+	}
+
+	samples := make([]Sample, opts.Reads)
+	field := make([]float64, n)
+	for r := 0; r < opts.Reads; r++ {
+		x := make([]bool, n)
+		randomize(x, rng)
+		setfield(field, x, diag, adj)
+		for _, beta := range betas {
+			sweep(x, field, adj, beta, rng)
+		}
+		samples[r] = Sample{x, adjenergy(diag, adj, x), 1}
+	}
+	return samples
+}
+
+/* sets every variable to 0 or 1 at random */
+func randomize(x []bool, rng *rand.Rand) {
+	for i := range x {
+		x[i] = rng.Intn(2) == 1
+	}
+}
+
+/* sets field[i] to the energy change of switching i on,
+ * given the other variables */
+func setfield(field []float64, x []bool, diag []float64, adj [][]Term) {
+	for i := range field {
+		field[i] = diag[i]
+		for _, t := range adj[i] {
+			if x[t.J] {
+				field[i] += t.C
+			}
+		}
+	}
+}
+
+/* Gives every variable in turn a Metropolis update at inverse
+ * temperature beta, keeping field up to date;
+ * returns the change in energy. */
+func sweep(x []bool, field []float64, adj [][]Term, beta float64, rng *rand.Rand) float64 {
+	change := float64(0)
+	for i := range x {
+		delta := flipdelta(x, field, i)
+		if delta > 0 && rng.Float64() >= math.Exp(-beta*delta) {
+			continue
+		}
+		flip(x, field, adj, i)
+		change += delta
+	}
+	return change
+}
+
+/* the energy change of switching variable i */
+func flipdelta(x []bool, field []float64, i int) float64 {
+	if x[i] {
+		return - field[i]
+	}
+	return field[i]
+}
+
+/* switches variable i, keeping field up to date */
+func flip(x []bool, field []float64, adj [][]Term, i int) {
+	x[i] = !x[i]
+	sign := float64(1)
+	if !x[i] {
+		sign = -1
+	}
+	for _, t := range adj[i] {
+		field[t.J] += sign * t.C
+	}
+}
+
+/* The default range of inverse temperatures:
+ * at the start, the largest possible increase in energy
+ * is accepted with probability one half,
+ * at the end, the smallest is accepted with probability 1%. */
+func betarange(diag []float64, adj [][]Term) (float64, float64) {
+	maxdelta := float64(0)
+	mindelta := math.Inf(1)
+	for i := range diag {
+		d := math.Abs(diag[i])
+		if d > 0 && d < mindelta {
+			mindelta = d
+		}
+		for _, t := range adj[i] {
+			c := math.Abs(t.C)
+			d += c
+			if c > 0 && c < mindelta {
+				mindelta = c
+			}
+		}
+		if d > maxdelta {
+			maxdelta = d
+		}
+	}
+	if maxdelta == 0 {
+		return 1, 1
+	}
+	return math.Log(2) / maxdelta, math.Log(100) / mindelta
+}
@@ -0,0 +1,489 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+/* The formulation with bounded cycle length is position-indexed:
+ * every cycle is led by its lowest vertex, every chain
+ * by its non-directed donor, and every arc has
+ * a copy per leader and per position it can take in such a cycle.
+ * Arcs leaving the leader are at position 1,
+ * and the flow entering a vertex at position p
+ * has to leave it at position p+1, unless it returns to the leader. */
+
+/* the variables for the arcs of the cycles led by leader
+ * that enter (or leave) vert at position pos */
+type slot struct {
+	leader int
+	vert int
+	pos int
+}
+
+/* an arc in the cycles led by leader, at position pos */
+type posarc struct {
+	leader int
+	pos int
+	start int
+	end int
+}
+
+/* the vertices that can be in the cycles (or chains) led by l */
+func layerverts(l int, ndd []bool, n int) []bool {
+	allowed := make([]bool, n)
+	for v := 0; v < n; v++ {
+		isndd := ndd != nil && ndd[v]
+		if ndd != nil && ndd[l] {
+			allowed[v] = v == l || !isndd
+		} else {
+			allowed[v] = v >= l && !isndd
+		}
+	}
+	return allowed
+}
+
+/* breadth-first distances from l (or to l, if !out)
+ * through the allowed vertices; -1 where unreachable */
+func layerdists(g Cwdgraph, l int, allowed []bool, out bool) []int {
+	dist := make([]int, g.N)
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[l] = 0
+	queue := []int{l}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		adj := g.Out[u]
+		if !out {
+			adj = g.In[u]
+		}
+		for _, k := range adj {
+			v := g.Arcs[k].End
+			if !out {
+				v = g.Arcs[k].Start
+			}
+			if allowed[v] && dist[v] < 0 {
+				dist[v] = dist[u] + 1
+				queue = append(queue, v)
+			}
+		}
+	}
+	return dist
+}
+
+/* calls f for every leader l, arc a and position p
+ * that arc a can take in the cycles (or chains) led by l
+ * without them getting longer than maxlen arcs (chainarcs for chains) */
+func layerarcs(g Cwdgraph, maxlen int, chainarcs int, f func(l int, a int, p int)) {
+	n := g.N
+	for l := 0; l < n; l++ {
+		allowed := layerverts(l, g.Ndd, n)
+		from := layerdists(g, l, allowed, true)
+		to := layerdists(g, l, allowed, false)
+		lmax := maxlen
+		if g.Ndd != nil && g.Ndd[l] {
+			lmax = chainarcs
+		}
+		for a, arc := range g.Arcs {
+			i, j := arc.Start, arc.End
+			if !allowed[i] || !allowed[j] || from[i] < 0 || to[j] < 0 {
+				continue
+			}
+			for p := from[i] + 1; p + to[j] <= lmax; p++ {
+				if i == l && p > 1 {
+					break
+				}
+				f(l, a, p)
+			}
+		}
+	}
+}
+
+/* the capacity of every copy of arc a */
+func arccap(g Cwdgraph, a int, adis bool) int {
+	if adis {
+		return 1
+	}
+	return g.Arcs[a].Cpty
+}
+
+/* Like Constructqubo, but no cycle gets longer than maxlen arcs
+ * and no chain longer than chainarcs arcs. Does not modify g. */
+func constructbounded(g Cwdgraph, penmult float64, vdis bool, adis bool, intenc Intencoding, maxlen int, chainarcs int, rings []int, ringfactor float64) (Qubo, Transltable) {
+	n := g.N
+
+	var tlt Transltable
+	vertvars := make([][]int, n)
+	vcaps := vertcaps(g)
+	for i := 0; i < n; i++ {
+		vcap := vcaps[i]
+		if vdis {
+			vcap = 1
+		}
+		for _, bv := range intenc.split(vcap) {
+			vertvars[i] = append(vertvars[i], len(tlt))
+			tlt = append(tlt, Translentry{Start: i, End: i, Bitval: bv})
+		}
+	}
+
+	outvars := make([][]int, n)
+	invars := make([][]int, n)
+	inslot := make(map[slot][]int)
+	outslot := make(map[slot][]int)
+	/* the variables of all copies of every arc, and how many copies */
+	copyvars := make([][]int, len(g.Arcs))
+	ncopies := make([]int, len(g.Arcs))
+	layerarcs(g, maxlen, chainarcs, func(l int, a int, p int) {
+		i, j := g.Arcs[a].Start, g.Arcs[a].End
+		ncopies[a]++
+		for _, bv := range intenc.split(arccap(g, a, adis)) {
+			k := len(tlt)
+			tlt = append(tlt, Translentry{i, j, bv, a, l, p})
+			outvars[i] = append(outvars[i], k)
+			invars[j] = append(invars[j], k)
+			inslot[slot{l, j, p}] = append(inslot[slot{l, j, p}], k)
+			outslot[slot{l, i, p}] = append(outslot[slot{l, i, p}], k)
+			copyvars[a] = append(copyvars[a], k)
+		}
+	})
+
+	/* the copies of an arc together carry at most its capacity;
+	 * a capacity of one needs no slack */
+	slackvars := make([][]int, len(g.Arcs))
+	for a, arc := range g.Arcs {
+		acap := arccap(g, a, adis)
+		if ncopies[a] < 2 || acap < 2 {
+			continue
+		}
+		for _, bv := range intenc.split(acap) {
+			slackvars[a] = append(slackvars[a], len(tlt))
+			tlt = append(tlt, Translentry{arc.Start, arc.End, bv, a, 0, -1})
+		}
+	}
+
+	nvar := len(tlt)
+	QUBO := Newqubo(nvar)
+
+	/* first the base objective */
+	for k := 0; k < nvar; k++ {
+		v := tlt[k]
+		if v.Pos > 0 {
+			QUBO.Add(k, k, - float64(g.Arcs[v.Arc].Wgt * v.Bitval))
+		}
+	}
+
+	for a, arc := range g.Arcs {
+		if ncopies[a] < 2 {
+			continue
+		}
+		localmult := penmult * math.Pow(ringfactor, float64(rings[arc.Start]))
+		if slackvars[a] == nil {
+			var used []int
+			for _, k := range copyvars[a] {
+				if tlt[k].Bitval > 0 {
+					used = append(used, k)
+				}
+			}
+			addpairpen(QUBO, used, localmult)
+			continue
+		}
+		var indices []int
+		var values []int
+		for _, k := range append(copyvars[a], slackvars[a]...) {
+			indices = append(indices, k)
+			values = append(values, tlt[k].Bitval)
+		}
+		addquadpen(&QUBO, indices, values, - arccap(g, a, adis), localmult)
+	}
+
+	/* the flow through a vertex equals its in- and out-flow */
+	for i := 0; i < n; i++ {
+		localmult := penmult * math.Pow(ringfactor, float64(rings[i]))
+		for _, arcvars := range [][]int{outvars[i], invars[i]} {
+			var indices []int
+			var values []int
+			for _, k := range vertvars[i] {
+				indices = append(indices, k)
+				values = append(values, tlt[k].Bitval)
+			}
+			for _, k := range arcvars {
+				indices = append(indices, k)
+				values = append(values, - tlt[k].Bitval)
+			}
+			addquadpen(&QUBO, indices, values, 0, localmult)
+		}
+	}
+
+	/* the flow moves on one position at every vertex
+	 * and returns to the leader */
+	for l := 0; l < n; l++ {
+		allowed := layerverts(l, g.Ndd, n)
+		for v := 0; v < n; v++ {
+			if !allowed[v] {
+				continue
+			}
+			localmult := penmult * math.Pow(ringfactor, float64(rings[v]))
+			var indices []int
+			var values []int
+			for p := 1; p <= maxlen || p <= chainarcs; p++ {
+				if v != l {
+					indices = indices[:0]
+					values = values[:0]
+				}
+				for _, k := range inslot[slot{l, v, p}] {
+					indices = append(indices, k)
+					values = append(values, tlt[k].Bitval)
+				}
+				var leaving []int
+				if v != l {
+					leaving = outslot[slot{l, v, p+1}]
+				} else if p == 1 {
+					leaving = outslot[slot{l, l, 1}]
+				}
+				for _, k := range leaving {
+					indices = append(indices, k)
+					values = append(values, - tlt[k].Bitval)
+				}
+				if v != l {
+					addquadpen(&QUBO, indices, values, 0, localmult)
+				}
+			}
+			if v == l {
+				addquadpen(&QUBO, indices, values, 0, localmult)
+			}
+		}
+	}
+
+	for _, group := range intgroups(tlt) {
+		localmult := penmult * math.Pow(ringfactor, float64(rings[ tlt[group[0]].Start ]))
+		intenc.addvalidity(&QUBO, group, localmult)
+	}
+
+	return QUBO, tlt
+}
+
+/* the flow along the arc copies of the bounded formulation */
+func layerflows(sol []bool, tlt Transltable) map[posarc]int {
+	flows := make(map[posarc]int)
+	for p, vrbl := range tlt {
+		if sol[p] && vrbl.Pos > 0 {
+			flows[posarc{vrbl.Leader, vrbl.Pos, vrbl.Start, vrbl.End}] += vrbl.Bitval
+		}
+	}
+	return flows
+}
+
+/* the in- and out-flow of every slot;
+ * the in-flow of a leader is collected at position 0 */
+func slotflows(flows map[posarc]int) (map[slot]int, map[slot]int) {
+	inflow := make(map[slot]int)
+	outflow := make(map[slot]int)
+	for pa, f := range flows {
+		if pa.end == pa.leader {
+			inflow[slot{pa.leader, pa.end, 0}] += f
+		} else {
+			inflow[slot{pa.leader, pa.end, pa.pos}] += f
+		}
+		outflow[slot{pa.leader, pa.start, pa.pos}] += f
+	}
+	return inflow, outflow
+}
+
+/* the slots where the flow does not continue correctly */
+func layerbreaks(sol []bool, tlt Transltable) []slot {
+	inflow, outflow := slotflows(layerflows(sol, tlt))
+	var breaks []slot
+	checked := make(map[slot]bool)
+	check := func(s slot) {
+		if checked[s] {
+			return
+		}
+		checked[s] = true
+		var in, out int
+		if s.vert == s.leader {
+			in = inflow[slot{s.leader, s.vert, 0}]
+			out = outflow[slot{s.leader, s.vert, 1}]
+		} else {
+			in = inflow[s]
+			out = outflow[slot{s.leader, s.vert, s.pos + 1}]
+		}
+		if in != out {
+			breaks = append(breaks, s)
+		}
+	}
+	for s := range inflow {
+		if s.vert == s.leader {
+			check(slot{s.leader, s.vert, 0})
+		} else {
+			check(s)
+		}
+	}
+	for s := range outflow {
+		if s.vert == s.leader {
+			check(slot{s.leader, s.vert, 0})
+		} else {
+			check(slot{s.leader, s.vert, s.pos - 1})
+		}
+	}
+	sort.Slice(breaks, func(p, q int) bool {
+		bp, bq := breaks[p], breaks[q]
+		if bp.leader != bq.leader {
+			return bp.leader < bq.leader
+		}
+		if bp.vert != bq.vert {
+			return bp.vert < bq.vert
+		}
+		return bp.pos < bq.pos
+	})
+	return breaks
+}
+
+func showlayerbreaks(out io.Writer, sol []bool, tlt Transltable, oldinds []int) {
+	inflow, outflow := slotflows(layerflows(sol, tlt))
+	for _, s := range layerbreaks(sol, tlt) {
+		if s.vert == s.leader {
+			fmt.Fprintf(out, "Break at vertex %d, leading its cycles\n", oldinds[s.vert])
+			fmt.Fprintf(out, "Returning: %d\n", inflow[slot{s.leader, s.vert, 0}])
+			fmt.Fprintf(out, "Leaving: %d\n", outflow[slot{s.leader, s.vert, 1}])
+		} else {
+			fmt.Fprintf(out, "Break at vertex %d, position %d in the cycles led by vertex %d\n", oldinds[s.vert], s.pos, oldinds[s.leader])
+			fmt.Fprintf(out, "In: %d\n", inflow[s])
+			fmt.Fprintf(out, "Out: %d\n", outflow[slot{s.leader, s.vert, s.pos + 1}])
+		}
+	}
+}
+
+/* the arcs whose copies together carry more than their capacity,
+ * or whose slack does not make up the difference */
+func capbreaks(sol []bool, tlt Transltable, g Cwdgraph, adis bool) []int {
+	used := make([]int, len(g.Arcs))
+	slack := make([]int, len(g.Arcs))
+	hasslack := make([]bool, len(g.Arcs))
+	for k, v := range tlt {
+		if v.Pos < 0 {
+			hasslack[v.Arc] = true
+		}
+		if !sol[k] {
+			continue
+		}
+		if v.Pos > 0 {
+			used[v.Arc] += v.Bitval
+		} else if v.Pos < 0 {
+			slack[v.Arc] += v.Bitval
+		}
+	}
+	var breaks []int
+	for a := range g.Arcs {
+		acap := arccap(g, a, adis)
+		if used[a] > acap || (hasslack[a] && used[a] + slack[a] != acap) {
+			breaks = append(breaks, a)
+		}
+	}
+	return breaks
+}
+
+func showcapbreaks(out io.Writer, sol []bool, tlt Transltable, g Cwdgraph, adis bool, oldinds []int) {
+	for _, a := range capbreaks(sol, tlt, g, adis) {
+		arc := g.Arcs[a]
+		fmt.Fprintf(out, "Break at arc %d -> %d: its copies and slack do not add up to its capacity %d\n", oldinds[arc.Start], oldinds[arc.End], arccap(g, a, adis))
+	}
+}
+
+/* Splits the flow of the bounded formulation into cycles,
+ * following the positions, so that none is longer than they allow.
+ * The flow has to be feasible. */
+func decompbounded(sol []bool, tlt Transltable, oldinds []int) []Cycle {
+	flows := layerflows(sol, tlt)
+	var keys []posarc
+	for pa := range flows {
+		keys = append(keys, pa)
+	}
+	sort.Slice(keys, func(p, q int) bool {
+		kp, kq := keys[p], keys[q]
+		if kp.leader != kq.leader {
+			return kp.leader < kq.leader
+		}
+		if kp.pos != kq.pos {
+			return kp.pos < kq.pos
+		}
+		if kp.start != kq.start {
+			return kp.start < kq.start
+		}
+		return kp.end < kq.end
+	})
+	next := make(map[slot][]posarc)
+	var leaders []int
+	for _, pa := range keys {
+		s := slot{pa.leader, pa.start, pa.pos}
+		next[s] = append(next[s], pa)
+		if pa.pos == 1 && (len(leaders) == 0 || leaders[len(leaders)-1] != pa.leader) {
+			leaders = append(leaders, pa.leader)
+		}
+	}
+
+	var cycles []Cycle
+	for _, l := range leaders {
+		for {
+			var walk []posarc
+			v := l
+			for p := 1; ; p++ {
+				found := false
+				for _, pa := range next[slot{l, v, p}] {
+					if flows[pa] > 0 {
+						walk = append(walk, pa)
+						v = pa.end
+						found = true
+						break
+					}
+				}
+				if !found || v == l {
+					break
+				}
+			}
+			if len(walk) == 0 || v != l {
+				break
+			}
+			min := flows[walk[0]]
+			for _, pa := range walk {
+				if flows[pa] < min {
+					min = flows[pa]
+				}
+			}
+			for _, pa := range walk {
+				flows[pa] -= min
+			}
+			cycles = append(cycles, splitwalk(walk, min, oldinds)...)
+		}
+	}
+	return cycles
+}
+
+/* splits a closed walk into simple cycles */
+func splitwalk(walk []posarc, flow int, oldinds []int) []Cycle {
+	var cycles []Cycle
+	stack := []int{walk[0].start}
+	for _, pa := range walk {
+		v := pa.end
+		q := -1
+		for r := range stack {
+			if stack[r] == v {
+				q = r
+			}
+		}
+		if q < 0 {
+			stack = append(stack, v)
+			continue
+		}
+		verts := make([]int, len(stack) - q)
+		for r := q; r < len(stack); r++ {
+			verts[r-q] = oldinds[stack[r]]
+		}
+		cycles = append(cycles, Cycle{flow, verts, false})
+		stack = stack[:q+1]
+	}
+	return cycles
+}
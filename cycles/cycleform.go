@@ -0,0 +1,172 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+/* In the cycle formulation, there is one variable per cycle
+ * of at most maxlen arcs; choosing two cycles that share a vertex
+ * (or an arc, in arc-disjoint mode) is penalised. */
+
+/* Lists the simple cycles of at most maxlen arcs,
+ * each starting from its lowest vertex,
+ * and the chains of at most chainarcs arcs (including the one
+ * to the waitlist), each starting from its non-directed donor. */
+func Enumcycles(g Cwdgraph, maxlen int, chainarcs int) [][]int {
+	n := g.N
+	ndd := g.Ndd
+	if ndd == nil {
+		ndd = make([]bool, n)
+	}
+	var cycles [][]int
+	onpath := make([]bool, n)
+	var path []int
+
+	var extend func(l int, u int, max int)
+	extend = func(l int, u int, max int) {
+		if len(path) > 1 && g.Find(u, l) >= 0 {
+			cycle := make([]int, len(path))
+			copy(cycle, path)
+			cycles = append(cycles, cycle)
+		}
+		if len(path) == max {
+			return
+		}
+		for _, k := range g.Out[u] {
+			v := g.Arcs[k].End
+			if (v > l || ndd[l]) && !onpath[v] && !ndd[v] {
+				onpath[v] = true
+				path = append(path, v)
+				extend(l, v, max)
+				path = path[:len(path)-1]
+				onpath[v] = false
+			}
+		}
+	}
+	for l := 0; l < n; l++ {
+		path = append(path[:0], l)
+		onpath[l] = true
+		if ndd[l] {
+			extend(l, l, chainarcs)
+		} else {
+			extend(l, l, maxlen)
+		}
+		onpath[l] = false
+	}
+	return cycles
+}
+
+/* the indices of the arcs of a cycle in g */
+func cyclearcs(cycle []int, g Cwdgraph) []int {
+	k := len(cycle)
+	arcs := make([]int, k)
+	for p := 0; p < k; p++ {
+		arcs[p] = g.Find(cycle[p], cycle[(p+1)%k])
+	}
+	return arcs
+}
+
+func cycleweight(cycle []int, g Cwdgraph) int {
+	sum := 0
+	for _, k := range cyclearcs(cycle, g) {
+		sum += g.Arcs[k].Wgt
+	}
+	return sum
+}
+
+/* modifies qubomatrix; penalises every pair among indices
+ * being switched on together by mult */
+func addpairpen(qubomatrix Qubo, indices []int, mult float64) {
+	k := len(indices)
+	for p := 0; p < k; p++ {
+		for q := p+1; q < k; q++ {
+			i := indices[p]
+			j := indices[q]
+			qubomatrix.Add(i, j, mult)
+		}
+	}
+}
+
+func constructcycleform(g Cwdgraph, cycles [][]int, penmult float64, vdis bool, rings []int, ringfactor float64) Qubo {
+	n := g.N
+	nvar := len(cycles)
+
+	QUBO := Newqubo(nvar)
+
+	for c, cycle := range cycles {
+		QUBO.Add(c, c, - float64(cycleweight(cycle, g)))
+	}
+
+	if vdis {
+		users := make([][]int, n)
+		for c, cycle := range cycles {
+			for _, v := range cycle {
+				users[v] = append(users[v], c)
+			}
+		}
+		for v := 0; v < n; v++ {
+			localmult := penmult * math.Pow(ringfactor, float64(rings[v]))
+			addpairpen(QUBO, users[v], localmult)
+		}
+	} else {
+		users := make([][]int, len(g.Arcs))
+		for c, cycle := range cycles {
+			for _, k := range cyclearcs(cycle, g) {
+				users[k] = append(users[k], c)
+			}
+		}
+		for k, arc := range g.Arcs {
+			localmult := penmult * math.Pow(ringfactor, float64(rings[arc.Start]))
+			addpairpen(QUBO, users[k], localmult)
+		}
+	}
+
+	return QUBO
+}
+
+/* the flows of the chosen cycles added together */
+func cycleflows(sol []bool, cycles [][]int, g Cwdgraph) ([]int, Flow) {
+	vertices := make([]int, g.N)
+	flow := make(Flow, len(g.Arcs))
+	for c, cycle := range cycles {
+		if !sol[c] {
+			continue
+		}
+		for _, v := range cycle {
+			vertices[v]++
+		}
+		for _, k := range cyclearcs(cycle, g) {
+			flow[k]++
+		}
+	}
+	return vertices, flow
+}
+
+/* reports the vertices (or arcs) used by more than one chosen cycle;
+ * returns whether there are any */
+func showconflicts(out io.Writer, sol []bool, cycles [][]int, g Cwdgraph, vdis bool, oldinds []int) bool {
+	vertices, flow := cycleflows(sol, cycles, g)
+	found := false
+	if vdis {
+		for i := 0; i < g.N; i++ {
+			if vertices[i] > 1 {
+				found = true
+				if out != nil {
+					fmt.Fprintf(out, "Vertex %d is in %d cycles; %d in simplified graph\n", oldinds[i], vertices[i], i)
+				}
+			}
+		}
+		return found
+	}
+	for k, arc := range g.Arcs {
+		if flow[k] > 1 {
+			found = true
+			if out != nil {
+				fmt.Fprintf(out, "Arc %d -> %d is in %d cycles\n", oldinds[arc.Start], oldinds[arc.End], flow[k])
+			}
+		}
+	}
+	return found
+}
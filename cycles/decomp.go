@@ -0,0 +1,156 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+)
+
+/* a cycle carrying Flow units; the last vertex connects back to the first;
+ * for a chain, the first vertex is the non-directed donor
+ * and the last one gives to the waitlist */
+type Cycle struct {
+	Flow int
+	Verts []int
+	Chain bool
+}
+
+/* Splits a circulation into cycles.
+ * The vertices in the result are translated with old_indices. */
+func Decomp(g Cwdgraph, flow Flow, old_indices []int) []Cycle {
+	n := g.N
+	flow = append(Flow{}, flow...)
+	/* where a vertex is on the path, or -1 */
+	onpath := make([]int, n)
+	for i := range onpath {
+		onpath[i] = -1
+	}
+	var path []int
+	var arcpath []int
+	var cycles []Cycle
+
+	next := 0
+	for {
+		for next < len(flow) && flow[next] <= 0 {
+			next++
+		}
+		if next == len(flow) {
+			break
+		}
+		u := g.Arcs[next].Start
+
+		for onpath[u] < 0 {
+			onpath[u] = len(path)
+			path = append(path, u)
+			for _, k := range g.Out[u] {
+				if flow[k] > 0 {
+					arcpath = append(arcpath, k)
+					u = g.Arcs[k].End
+					break
+				}
+			}
+		}
+
+		start := onpath[u]
+		min := flow[ arcpath[start] ]
+		for _, k := range arcpath[start:] {
+			if flow[k] < min {
+				min = flow[k]
+			}
+		}
+		for _, k := range arcpath[start:] {
+			flow[k] -= min
+		}
+
+		verts := make([]int, len(path) - start)
+		for p := start; p < len(path); p++ {
+			verts[p-start] = old_indices[ path[p] ]
+		}
+		cycles = append(cycles, Cycle{min, verts, false})
+
+		for _, v := range path {
+			onpath[v] = -1
+		}
+		path = path[0:0]
+		arcpath = arcpath[0:0]
+	}
+	return cycles
+}
+
+func Printdecomp(out io.Writer, g Cwdgraph, flow Flow, old_indices []int) {
+	Printcycles(out, Decomp(g, flow, old_indices))
+}
+
+/* prints the cycles, and then the chains */
+func Printcycles(out io.Writer, cycles []Cycle) {
+	for _, c := range cycles {
+		if c.Chain {
+			continue
+		}
+		fmt.Fprintf(out, "Flow of %d along ", c.Flow)
+		for _, v := range c.Verts {
+			fmt.Fprintf(out, "%d -> ", v)
+		}
+		fmt.Fprintf(out, "%d\n", c.Verts[0])
+	}
+	for _, c := range cycles {
+		if !c.Chain {
+			continue
+		}
+		fmt.Fprintf(out, "Chain of %d along ", c.Flow)
+		for _, v := range c.Verts {
+			fmt.Fprintf(out, "%d -> ", v)
+		}
+		fmt.Fprintf(out, "waitlist\n")
+	}
+}
+
+/* Marks the cycles through one of the non-directed donors in ndds
+ * as chains, starting them at the donor.
+ * A cycle through several donors is several chains,
+ * each ending in the waitlist before the next donor. */
+func Markchains(cycles []Cycle, ndds []int) []Cycle {
+	isndd := make(map[int]bool)
+	for _, d := range ndds {
+		isndd[d] = true
+	}
+	var marked []Cycle
+	for _, c := range cycles {
+		segments := chainsegments(c.Verts, isndd)
+		if segments == nil {
+			marked = append(marked, c)
+			continue
+		}
+		for _, seg := range segments {
+			marked = append(marked, Cycle{c.Flow, seg[:len(seg)-1], true})
+		}
+	}
+	return marked
+}
+
+/* Splits a cycle at its non-directed donors; every segment
+ * starts at a donor and ends with the next one.
+ * Returns nil if there are none. */
+func chainsegments(verts []int, isndd map[int]bool) [][]int {
+	k := len(verts)
+	first := -1
+	for p, v := range verts {
+		if isndd[v] {
+			first = p
+			break
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	var segments [][]int
+	seg := []int{verts[first]}
+	for q := 1; q <= k; q++ {
+		v := verts[(first+q)%k]
+		seg = append(seg, v)
+		if isndd[v] {
+			segments = append(segments, seg)
+			seg = []int{v}
+		}
+	}
+	return segments
+}
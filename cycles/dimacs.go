@@ -0,0 +1,82 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+	"bufio"
+)
+
+/* Writes g as a DIMACS minimum-cost flow problem
+ * in which every arc starts out saturated;
+ * the solution says how much flow to take back from each arc,
+ * so the arc costs are the weights. */
+func Writedimacs(out io.Writer, g Cwdgraph) error {
+	f := bufio.NewWriter(out)
+
+	m := 0
+	for _, arc := range g.Arcs {
+		if arc.Cpty > 0 {
+			m++
+		}
+	}
+	fmt.Fprintf(f, "p min %d %d\n", g.N, m)
+
+	full := make(Flow, len(g.Arcs))
+	for k, arc := range g.Arcs {
+		full[k] = arc.Cpty
+	}
+	isums, osums := inout(g, full)
+	for i := 0; i < g.N; i++ {
+		s := osums[i] - isums[i]
+		if s != 0 {
+			fmt.Fprintf(f, "n %d %d\n", i+1, s)
+		}
+	}
+
+	for _, arc := range g.Arcs {
+		if arc.Cpty > 0 {
+			fmt.Fprintf(f, "a %d %d 0 %d %d\n", arc.Start+1, arc.End+1, arc.Cpty, arc.Wgt)
+		}
+	}
+	return f.Flush()
+}
+
+/* Reads the solution to the problem written by Writedimacs
+ * and returns the flow it stands for. */
+func Readdimacs(in io.Reader, g Cwdgraph) (Flow, error) {
+	s := bufio.NewScanner(in)
+
+	flow := make(Flow, len(g.Arcs))
+	for k, arc := range g.Arcs {
+		flow[k] = arc.Cpty
+	}
+
+	var i int
+	var j int
+	var back int
+	var line string
+	for s.Scan() {
+		line = s.Text()
+		if len(line) == 0 || line[0] != 'f' {
+			continue
+		}
+		_, err := fmt.Sscanf(line, "f %d %d %d", &i, &j, &back)
+		if err != nil {
+			return nil, fmt.Errorf("malformed flow entry in input: %q", line)
+		}
+		i--
+		j--
+		k := -1
+		if i >= 0 && i < g.N && j >= 0 && j < g.N {
+			k = g.Find(i, j)
+		}
+		if k < 0 {
+			if back == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("flow entry for a nonexistent arc: %q", line)
+		}
+		flow[k] -= back
+	}
+	return flow, s.Err()
+}
@@ -0,0 +1,283 @@
+package cycles
+
+import (
+	"io"
+	"math"
+	"encoding/json"
+)
+
+/* the options that determine how a graph is turned into a QUBO */
+type Encopts struct {
+	/* the penalty multiplier; relative to the adjusted average
+	 * of the weights unless Absmult is set */
+	Mult float64
+	Absmult bool
+	/* the penalties grow by this factor from the first ring to the last */
+	Ringf float64
+	Vdis bool
+	Adis bool
+	/* the maximum number of arcs in a cycle; no maximum if zero */
+	Maxlen int `json:",omitempty"`
+	/* one variable per cycle instead of per arc and vertex;
+	 * needs Maxlen and one of Adis and Vdis */
+	Cycleform bool `json:",omitempty"`
+	/* the maximum number of transplants in a chain;
+	 * with a maximum cycle length, the default is one less than it,
+	 * otherwise chains are not limited */
+	Chainlen int `json:",omitempty"`
+	/* the penalties are relative to the safe bound
+	 * instead of the adjusted average, unless Absmult is set */
+	Safe bool `json:",omitempty"`
+	/* how the flows are spread over variables,
+	 * if not with one variable per cycle */
+	Intenc Intencoding `json:",omitempty"`
+	/* no variables for the flow through the vertices;
+	 * needs Adis, and cannot be used with Maxlen or Cycleform */
+	Noslack bool `json:",omitempty"`
+}
+
+/* Everything needed to translate samples of the QUBO
+ * back into a solution on the original graph. */
+type Encoding struct {
+	Opts Encopts
+	/* the penalty multiplier actually used,
+	 * before scaling by the ring factor */
+	Penmult float64
+	/* the adjusted average of the weights, if Absmult was not set,
+	 * even if the penalties follow the safe bound */
+	Avg float64
+	/* the smallest penalty for violating a constraint,
+	 * and the bound it has to reach to be sure that
+	 * no infeasible assignment has the lowest energy;
+	 * Encode only finds the latter with Safe, see Safebound */
+	Minpen float64
+	Bound float64
+	/* the constant of the QUBO, which samplers leave out */
+	Offset float64 `json:",omitempty"`
+	Tlt Transltable
+	/* the cycles of the cycle formulation, in the simplified graph */
+	Cycles [][]int `json:",omitempty"`
+	Oldinds []int
+	/* the non-directed donors, in the original graph */
+	Ndds []int `json:",omitempty"`
+	/* the simplified graph */
+	Graph Cwdgraph
+}
+
+/* Builds the QUBO for the simplified graph g;
+ * oldinds is the mapping returned by Simplify. Does not modify g. */
+func Encode(g Cwdgraph, oldinds []int, opts Encopts) (Qubo, Encoding) {
+	enc := Encoding{Opts: opts, Oldinds: oldinds, Graph: g.Copy()}
+	enc.Ndds = Nddlist(g.Ndd, oldinds)
+
+	maxlen, chainarcs := enc.lengths()
+
+	if opts.Cycleform {
+		enc.Cycles = Enumcycles(g, maxlen, chainarcs)
+	}
+
+	penmult := opts.Mult
+	if !opts.Absmult {
+		/* scale the penalties like what they compete with */
+		var weights []int
+		if opts.Cycleform {
+			for _, cycle := range enc.Cycles {
+				weights = append(weights, cycleweight(cycle, g))
+			}
+		} else {
+			for _, arc := range g.Arcs {
+				if arc.Wgt > 0 {
+					weights = append(weights, arc.Wgt)
+				}
+			}
+		}
+		enc.Avg = Adjustedavg(weights)
+		penmult *= enc.Avg
+	}
+	if !opts.Absmult && opts.Safe {
+		/* anything will do, the penalties are scaled afterwards */
+		penmult = 1
+	}
+	rings, max := Mkrings(g)
+	ringfactor := float64(1)
+	if max > 0 {
+		ringfactor = math.Pow(opts.Ringf, 1/float64(max))
+	}
+	penmult *= 1/ringfactor
+	/* the penalties of the vertices in the first ring
+	 * or in the last, whichever are lower */
+	minscale := math.Min(ringfactor, math.Pow(ringfactor, float64(max)))
+
+	var problem Qubo
+	if opts.Cycleform {
+		problem = constructcycleform(g, enc.Cycles, penmult, opts.Vdis, rings, ringfactor)
+	} else if enc.bounded() {
+		problem, enc.Tlt = constructbounded(g, penmult, opts.Vdis, opts.Adis, opts.Intenc, maxlen, chainarcs, rings, ringfactor)
+	} else {
+		problem, enc.Tlt = Constructqubo(g.Copy(), penmult, opts.Vdis, opts.Adis, opts.Noslack, opts.Intenc, rings, ringfactor)
+	}
+	if !opts.Absmult && opts.Safe {
+		enc.Bound = enc.Safebound()
+		safemult := opts.Mult * enc.Bound / minscale
+		enc.scalepenalties(&problem, safemult / penmult)
+		penmult = safemult
+	}
+	enc.Penmult = penmult
+	enc.Minpen = penmult * minscale
+	enc.Offset = problem.Offset
+	return problem, enc
+}
+
+/* whether the position-indexed formulation is used */
+func (enc Encoding) bounded() bool {
+	return enc.Opts.Maxlen > 0 || (enc.Opts.Chainlen > 0 && len(enc.Ndds) > 0)
+}
+
+/* the longest cycles and chains, in arcs, that the encoding allows;
+ * zero if not limited */
+func (enc Encoding) lengths() (int, int) {
+	maxlen := enc.Opts.Maxlen
+	if maxlen == 0 && enc.bounded() {
+		maxlen = enc.Graph.N
+	}
+	chainarcs := enc.Opts.Chainlen + 1
+	if enc.Opts.Chainlen == 0 {
+		chainarcs = maxlen
+	}
+	return maxlen, chainarcs
+}
+
+/* the number of variables of the QUBO */
+func (enc Encoding) Nvar() int {
+	if enc.Opts.Cycleform {
+		return len(enc.Cycles)
+	}
+	return len(enc.Tlt)
+}
+
+/* The number of variables that Encode would give the QUBO for g,
+ * counted without building it. */
+func Countvars(g Cwdgraph, oldinds []int, opts Encopts) int {
+	enc := Encoding{Opts: opts, Ndds: Nddlist(g.Ndd, oldinds), Graph: g}
+	maxlen, chainarcs := enc.lengths()
+	if opts.Cycleform {
+		return len(Enumcycles(g, maxlen, chainarcs))
+	}
+	nvar := 0
+	vcaps := vertcaps(g)
+	for i := 0; i < g.N && !opts.Noslack; i++ {
+		if opts.Vdis {
+			vcaps[i] = 1
+		}
+		nvar += len(opts.Intenc.split(vcaps[i]))
+	}
+	if enc.bounded() {
+		ncopies := make([]int, len(g.Arcs))
+		layerarcs(g, maxlen, chainarcs, func(l int, a int, p int) {
+			ncopies[a]++
+			nvar += len(opts.Intenc.split(arccap(g, a, opts.Adis)))
+		})
+		/* the slack of the arcs whose copies share their capacity */
+		for a := range g.Arcs {
+			if acap := arccap(g, a, opts.Adis); ncopies[a] > 1 && acap > 1 {
+				nvar += len(opts.Intenc.split(acap))
+			}
+		}
+	} else {
+		for _, arc := range g.Arcs {
+			acap := arc.Cpty
+			if opts.Adis && acap > 0 {
+				acap = 1
+			}
+			nvar += len(opts.Intenc.split(acap))
+		}
+	}
+	return nvar
+}
+
+/* the flows through the vertices and along the arcs of a sample;
+ * without vertex variables, the former are nil */
+func (enc Encoding) Flows(sol []bool) ([]int, Flow) {
+	g := enc.Graph
+	if enc.Opts.Cycleform {
+		return cycleflows(sol, enc.Cycles, g)
+	}
+	vertflows, arcflows := Backtranslate(sol, enc.Tlt, g.N, len(g.Arcs))
+	if enc.Opts.Noslack {
+		vertflows = nil
+	}
+	return vertflows, arcflows
+}
+
+func (enc Encoding) Feasible(sol []bool) bool {
+	if enc.Opts.Cycleform {
+		return !showconflicts(nil, sol, enc.Cycles, enc.Graph, enc.Opts.Vdis, enc.Oldinds)
+	}
+	vertflows, arcflows := enc.Flows(sol)
+	if !Isfeasible(enc.Graph, vertflows, arcflows) {
+		return false
+	}
+	if len(intbreaks(sol, enc.Tlt, enc.Opts.Intenc)) > 0 {
+		return false
+	}
+	if enc.Opts.Noslack && enc.Opts.Vdis && len(overloaded(enc.Graph, arcflows)) > 0 {
+		return false
+	}
+	if enc.bounded() {
+		return len(layerbreaks(sol, enc.Tlt)) == 0 && len(capbreaks(sol, enc.Tlt, enc.Graph, enc.Opts.Adis)) == 0
+	}
+	return true
+}
+
+/* the cycles of a feasible sample, in terms of the original graph */
+func (enc Encoding) Decomp(sol []bool) []Cycle {
+	if enc.Opts.Cycleform {
+		var cycles []Cycle
+		for c, cycle := range enc.Cycles {
+			if !sol[c] {
+				continue
+			}
+			verts := make([]int, len(cycle))
+			for p, v := range cycle {
+				verts[p] = enc.Oldinds[v]
+			}
+			cycles = append(cycles, Cycle{1, verts, false})
+		}
+		return Markchains(cycles, enc.Ndds)
+	}
+	if enc.bounded() {
+		return Markchains(decompbounded(sol, enc.Tlt, enc.Oldinds), enc.Ndds)
+	}
+	_, arcflows := enc.Flows(sol)
+	return Markchains(Decomp(enc.Graph, arcflows, enc.Oldinds), enc.Ndds)
+}
+
+/* reports why a sample is not feasible */
+func (enc Encoding) Showbreaks(out io.Writer, sol []bool) {
+	if enc.Opts.Cycleform {
+		showconflicts(out, sol, enc.Cycles, enc.Graph, enc.Opts.Vdis, enc.Oldinds)
+		return
+	}
+	vertflows, arcflows := enc.Flows(sol)
+	Showbreaks(out, enc.Graph, vertflows, arcflows, enc.Oldinds)
+	showintbreaks(out, sol, enc.Tlt, enc.Opts.Intenc, enc.Oldinds)
+	if enc.Opts.Noslack && enc.Opts.Vdis {
+		showoverloaded(out, enc.Graph, arcflows, enc.Oldinds)
+	}
+	if enc.bounded() {
+		showlayerbreaks(out, sol, enc.Tlt, enc.Oldinds)
+		showcapbreaks(out, sol, enc.Tlt, enc.Graph, enc.Opts.Adis, enc.Oldinds)
+	}
+}
+
+func Writeencoding(out io.Writer, enc Encoding) error {
+	e := json.NewEncoder(out)
+	e.SetIndent("", "\t")
+	return e.Encode(enc)
+}
+
+func Readencoding(in io.Reader) (Encoding, error) {
+	var enc Encoding
+	err := json.NewDecoder(in).Decode(&enc)
+	return enc, err
+}
@@ -0,0 +1,109 @@
+package cycles
+
+import (
+	"math"
+	"math/bits"
+)
+
+/* the outcome of trying every assignment of a QUBO */
+type Exhausted struct {
+	/* the lowest energy, offset included,
+	 * how many assignments reach it, and the first of those */
+	Energy float64
+	Nground int
+	Ground [][]bool
+	/* the feasible assignment with the lowest energy, nil if none */
+	Feasible []bool
+	Feasenergy float64
+}
+
+/* the most ground states that Exhaust keeps */
+const maxground = 64
+
+/* after how many flips the energy is computed anew,
+ * so that rounding errors do not pile up */
+const resync = 1 << 20
+
+/* Tries all 2^N assignments of problem, in Gray-code order
+ * so that every next one differs in one variable
+ * and its energy follows from that of the last one.
+ * feasible, if not nil, says which assignments are feasible;
+ * it is only asked about those with a lower energy
+ * than the best feasible one so far. Needs N < 64. */
+func Exhaust(problem Qubo, feasible func([]bool) bool) Exhausted {
+	n := problem.N
+	diag, adj := problem.adjacency()
+	tol := tolerance(problem)
+
+	x := make([]bool, n)
+	field := make([]float64, n)
+	setfield(field, x, diag, adj)
+	energy := 0.0
+
+	res := Exhausted{Energy: math.Inf(1), Feasenergy: math.Inf(1)}
+	record := func() {
+		if energy < res.Energy - tol {
+			res.Energy = energy
+			res.Nground = 0
+			res.Ground = res.Ground[:0]
+		}
+		if energy <= res.Energy + tol {
+			res.Nground++
+			if len(res.Ground) < maxground {
+				res.Ground = append(res.Ground, append([]bool{}, x...))
+			}
+		}
+		if feasible != nil && energy < res.Feasenergy - tol && feasible(x) {
+			res.Feasenergy = energy
+			if res.Feasible == nil {
+				/* not nil even without variables */
+				res.Feasible = make([]bool, 0, n)
+			}
+			res.Feasible = append(res.Feasible[:0], x...)
+		}
+	}
+
+	record()
+	for step := uint64(1); step < 1 << uint(n); step++ {
+		i := bits.TrailingZeros64(step)
+		energy += flipdelta(x, field, i)
+		flip(x, field, adj, i)
+		if step % resync == 0 {
+			energy = adjenergy(diag, adj, x)
+			setfield(field, x, diag, adj)
+		}
+		record()
+	}
+
+	/* the energies as they are, without the rounding errors;
+	 * adding 0 turns -0 into 0 */
+	res.Energy = Energy(problem, res.Ground[0]) + problem.Offset + 0
+	if res.Feasible != nil {
+		res.Feasenergy = Energy(problem, res.Feasible) + problem.Offset + 0
+	}
+	return res
+}
+
+/* The value of the best solution of the flow formulation
+ * of the encoded graph, found classically (like cycleclas does),
+ * for comparison with what the QUBO gives;
+ * false if the encoding limits the cycles or the chains,
+ * which the flow formulation cannot. */
+func (enc Encoding) Optimum() (int, bool) {
+	if enc.bounded() || enc.Opts.Cycleform {
+		return 0, false
+	}
+	g := enc.Graph.Copy()
+	if enc.Opts.Adis {
+		Setcaps1(g)
+	}
+	network := g
+	if enc.Opts.Vdis {
+		network = Vdis2adis(g)
+	}
+	flow := Maxcirculation(network)
+	if enc.Opts.Vdis {
+		flow = Adis2vdis(g, flow)
+	}
+	return Solval(g, flow), true
+}
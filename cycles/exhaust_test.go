@@ -0,0 +1,90 @@
+package cycles
+
+import (
+	"math"
+	"testing"
+)
+
+/* With the safe bound, the ground state of the QUBO
+ * is the optimum of the flow formulation. */
+func TestExhaustoptimum(t *testing.T) {
+	variants := map[string]Encopts{
+		"": {},
+		"-a": {Adis: true},
+		"-v": {Vdis: true, Adis: true},
+		"-a -n -e onehot": {Adis: true, Noslack: true, Intenc: Onehot},
+	}
+	g := testgraph()
+	oldinds := []int{0, 1, 2, 3, 4}
+	for name, opts := range variants {
+		opts.Mult = 1
+		opts.Ringf = 1
+		opts.Safe = true
+		problem, enc := Encode(g, oldinds, opts)
+		opt, ok := enc.Optimum()
+		if !ok {
+			t.Fatalf("%s: no optimum of the flow formulation", name)
+		}
+		res := Exhaust(problem, enc.Feasible)
+		if res.Feasible == nil {
+			t.Errorf("%s: no feasible assignment", name)
+			continue
+		}
+		if value := enc.Value(res.Feasible); value != opt {
+			t.Errorf("%s: the best feasible assignment has value %d instead of %d", name, value, opt)
+		}
+		if math.Abs(res.Energy + float64(opt)) > 1e-9 {
+			t.Errorf("%s: the lowest energy is %g instead of %d", name, res.Energy, -opt)
+		}
+	}
+}
+
+/* The copies of an arc at the different positions
+ * share its capacity, which the arc 1 -> 2 limits. */
+func TestExhaustbounded(t *testing.T) {
+	arcs := []Arc{
+		{0, 1, 1, 4},
+		{1, 2, 2, 5},
+		{2, 0, 1, 2},
+		{2, 3, 1, 3},
+		{3, 1, 1, 6},
+		{1, 0, 1, 1},
+	}
+	g := Newgraph(4, arcs, nil)
+	oldinds := []int{0, 1, 2, 3}
+	variants := []struct {
+		name string
+		opts Encopts
+		opt int
+	}{
+		{"-k 2", Encopts{Maxlen: 2}, 5},
+		{"-k 3", Encopts{Maxlen: 3}, 25},
+		{"-a -k 3", Encopts{Adis: true, Maxlen: 3}, 19},
+	}
+	for _, v := range variants {
+		v.opts.Mult = 1
+		v.opts.Ringf = 1
+		v.opts.Safe = true
+		problem, enc := Encode(g, oldinds, v.opts)
+		res := Exhaust(problem, enc.Feasible)
+		if res.Feasible == nil {
+			t.Errorf("%s: no feasible assignment", v.name)
+			continue
+		}
+		if value := enc.Value(res.Feasible); value != v.opt {
+			t.Errorf("%s: the best feasible assignment has value %d instead of %d", v.name, value, v.opt)
+		}
+		if math.Abs(res.Energy + float64(v.opt)) > 1e-9 {
+			t.Errorf("%s: the lowest energy is %g instead of %d", v.name, res.Energy, -v.opt)
+		}
+	}
+}
+
+/* The empty assignment of a QUBO without variables is feasible. */
+func TestExhaustempty(t *testing.T) {
+	_, enc := Encode(Newgraph(0, nil, nil), nil, Encopts{Mult: 1, Ringf: 1})
+	res := Exhaust(Newqubo(0), enc.Feasible)
+	if res.Feasible == nil || res.Nground != 1 || res.Energy != 0 || math.Signbit(res.Energy) {
+		t.Errorf("got %+v", res)
+	}
+}
@@ -0,0 +1,232 @@
+/* Package cycles contains the graph model and the processing steps
+ * shared by cycleclas, cyclequbo and mkinstance:
+ * reading and writing graphs, pre-processing, encoding as a QUBO,
+ * verification of solutions and their decomposition into cycles. */
+package cycles
+
+import (
+	"encoding/json"
+)
+
+/* dense matrices, only used for reading and writing graphs
+ * in the matrix format */
+type Capmat [][]int
+type Wgtmat [][]int
+
+type Arc struct {
+	Start int
+	End int
+	Cpty int
+	Wgt int
+}
+
+/* capacitated weighted directed graph, as a list of arcs;
+ * Out and In hold the indices of the arcs leaving and entering
+ * every vertex, and Ndd marks the non-directed donors, if there are any */
+type Cwdgraph struct {
+	N int
+	Arcs []Arc
+	Out [][]int
+	In [][]int
+	Ndd []bool
+}
+
+/* the amount of flow along every arc of a graph, by index */
+type Flow []int
+
+func Newgraph(n int, arcs []Arc, ndd []bool) Cwdgraph {
+	out := make([][]int, n)
+	in := make([][]int, n)
+	for k, arc := range arcs {
+		out[arc.Start] = append(out[arc.Start], k)
+		in[arc.End] = append(in[arc.End], k)
+	}
+	return Cwdgraph{n, arcs, out, in, ndd}
+}
+
+/* the index of the arc (i, j), or -1 if there is none */
+func (g Cwdgraph) Find(i int, j int) int {
+	for _, k := range g.Out[i] {
+		if g.Arcs[k].End == j {
+			return k
+		}
+	}
+	return -1
+}
+
+/* a copy whose capacities can be changed without affecting g */
+func (g Cwdgraph) Copy() Cwdgraph {
+	arcs := make([]Arc, len(g.Arcs))
+	copy(arcs, g.Arcs)
+	return Cwdgraph{g.N, arcs, g.Out, g.In, g.Ndd}
+}
+
+/* the graph with the arcs of positive capacity in a;
+ * loops are left out */
+func Fromdense(a Capmat, w Wgtmat, ndd []bool) Cwdgraph {
+	n := len(a)
+	var arcs []Arc
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if a[i][j] > 0 && i != j {
+				arcs = append(arcs, Arc{i, j, a[i][j], w[i][j]})
+			}
+		}
+	}
+	return Newgraph(n, arcs, ndd)
+}
+
+func (g Cwdgraph) Dense() (Capmat, Wgtmat) {
+	a := newmat(g.N)
+	w := newmat(g.N)
+	for _, arc := range g.Arcs {
+		a[arc.Start][arc.End] = arc.Cpty
+		w[arc.Start][arc.End] = arc.Wgt
+	}
+	return a, w
+}
+
+/* the adjacency lists are left out of the JSON representation */
+type jsongraph struct {
+	N int
+	Arcs []Arc
+	Ndd []bool `json:",omitempty"`
+}
+
+func (g Cwdgraph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsongraph{g.N, g.Arcs, g.Ndd})
+}
+
+func (g *Cwdgraph) UnmarshalJSON(data []byte) error {
+	var jg jsongraph
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+	*g = Newgraph(jg.N, jg.Arcs, jg.Ndd)
+	return nil
+}
+
+/* an n by n matrix backed by a single slice */
+func newmat(n int) [][]int {
+	under := make([]int, n*n)
+	mat := make([][]int, n)
+	for i := 0; i < n; i++ {
+		mat[i] = under[i*n : (i+1)*n]
+	}
+	return mat
+}
+
+/* the capacity of every vertex:
+ * the smaller of its total in- and out-capacity */
+func vertcaps(g Cwdgraph) []int {
+	caps := make([]int, g.N)
+	for i := 0; i < g.N; i++ {
+		isum := 0
+		osum := 0
+		for _, k := range g.Out[i] {
+			osum += g.Arcs[k].Cpty
+		}
+		for _, k := range g.In[i] {
+			isum += g.Arcs[k].Cpty
+		}
+		if osum > isum {
+			caps[i] = isum
+		} else {
+			caps[i] = osum
+		}
+	}
+	return caps
+}
+
+/* Splits every vertex i into 2i and 2i+1, joined by an arc
+ * of capacity 1, so that it can only be used once.
+ * The arcs of g come after those n arcs, in the same order. */
+func Vdis2adis(g Cwdgraph) Cwdgraph {
+	n := g.N
+	arcs := make([]Arc, n, n + len(g.Arcs))
+	var newndd []bool
+	if g.Ndd != nil {
+		newndd = make([]bool, 2*n)
+	}
+
+	for i := 0; i < n; i++ {
+		arcs[i] = Arc{2*i, 2*i+1, 1, 0}
+		if g.Ndd != nil {
+			newndd[2*i] = g.Ndd[i]
+			newndd[2*i+1] = g.Ndd[i]
+		}
+	}
+	for _, arc := range g.Arcs {
+		arcs = append(arcs, Arc{2*arc.Start+1, 2*arc.End, arc.Cpty, arc.Wgt})
+	}
+
+	return Newgraph(2*n, arcs, newndd)
+}
+
+/* translates a flow in Vdis2adis(g) back to g */
+func Adis2vdis(g Cwdgraph, adisflow Flow) Flow {
+	flow := make(Flow, len(g.Arcs))
+	copy(flow, adisflow[g.N:])
+	return flow
+}
+
+/* modifies g */
+func Setcaps1(g Cwdgraph) {
+	for k := range g.Arcs {
+		if g.Arcs[k].Cpty > 0 {
+			g.Arcs[k].Cpty = 1
+		}
+	}
+}
+
+/* Lets the chains started by non-directed donors end in the waitlist:
+ * every vertex gets an arc of weight zero to every non-directed donor,
+ * so that a chain becomes a cycle through its donor.
+ * Arcs into the non-directed donors that were there are removed. */
+func Addchainarcs(g Cwdgraph) Cwdgraph {
+	if !hasndd(g.Ndd) {
+		return g
+	}
+	n := g.N
+	dcaps := make([]int, n)
+	var arcs []Arc
+	for _, arc := range g.Arcs {
+		if g.Ndd[arc.Start] {
+			dcaps[arc.Start] += arc.Cpty
+		}
+		if !g.Ndd[arc.End] {
+			arcs = append(arcs, arc)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if g.Ndd[i] {
+			continue
+		}
+		for d := 0; d < n; d++ {
+			if g.Ndd[d] && dcaps[d] > 0 {
+				arcs = append(arcs, Arc{i, d, dcaps[d], 0})
+			}
+		}
+	}
+	return Newgraph(n, arcs, g.Ndd)
+}
+
+func hasndd(ndd []bool) bool {
+	for _, isndd := range ndd {
+		if isndd {
+			return true
+		}
+	}
+	return false
+}
+
+/* the vertices marked in ndd, translated with oldinds */
+func Nddlist(ndd []bool, oldinds []int) []int {
+	var list []int
+	for i := range ndd {
+		if ndd[i] {
+			list = append(list, oldinds[i])
+		}
+	}
+	return list
+}
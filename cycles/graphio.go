@@ -0,0 +1,344 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+	"errors"
+	"bufio"
+	"encoding/csv"
+)
+
+/* Reads a graph in either of two formats.
+ * The matrix format is a tab-separated n by n matrix of capacities
+ * followed by an n by n matrix of weights
+ * and optionally a row that has a 1 for every non-directed donor.
+ * The arc list format is described at Readarcs. */
+func Readgraph(in io.Reader) (Cwdgraph, error) {
+	br := bufio.NewReader(in)
+	first, err := br.Peek(2)
+	if err == nil && first[0] == 'n' && first[1] == '\t' {
+		return Readarcs(br)
+	}
+	r := csv.NewReader(br)
+	r.Comma = '\t'
+
+	firstrow, err := r.Read()
+	if err != nil {
+		return Cwdgraph{}, err
+	}
+	n := len(firstrow)
+
+	capacities := newmat(n)
+	if err := scanrow(firstrow, capacities[0]); err != nil {
+		return Cwdgraph{}, err
+	}
+	for i := 1; i < n; i++ {
+		row, err := r.Read()
+		if err != nil {
+			return Cwdgraph{}, err
+		}
+		if err := scanrow(row, capacities[i]); err != nil {
+			return Cwdgraph{}, err
+		}
+	}
+
+	weights := newmat(n)
+	for i := 0; i < n; i++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return Cwdgraph{}, errors.New("graph file ends before the weight matrix is complete")
+		}
+		if err != nil {
+			return Cwdgraph{}, err
+		}
+		if err := scanrow(row, weights[i]); err != nil {
+			return Cwdgraph{}, err
+		}
+	}
+
+	var ndd []bool
+	row, err := r.Read()
+	if err == nil {
+		ndd = make([]bool, n)
+		flags := make([]int, n)
+		if err := scanrow(row, flags); err != nil {
+			return Cwdgraph{}, err
+		}
+		for i := 0; i < n; i++ {
+			ndd[i] = flags[i] != 0
+		}
+	} else if err != io.EOF {
+		return Cwdgraph{}, err
+	}
+
+	return Fromdense(capacities, weights, ndd), nil
+}
+
+func scanrow(row []string, dest []int) error {
+	if len(row) != len(dest) {
+		return fmt.Errorf("row in graph file has %d entries, expected %d", len(row), len(dest))
+	}
+	for j := range dest {
+		if _, err := fmt.Sscanf(row[j], "%d", &dest[j]); err != nil {
+			return fmt.Errorf("malformed entry in graph file: %q", row[j])
+		}
+	}
+	return nil
+}
+
+/* writes g in the matrix format */
+func Writegraph(out io.Writer, g Cwdgraph) error {
+	w := csv.NewWriter(out)
+	w.Comma = '\t'
+
+	arcs, weights := g.Dense()
+	n := g.N
+
+	rep := make([][]string, 2*n)
+	i := 0
+	for ; i < n; i++ {
+		row := make([]string, n)
+		for j := 0; j < n; j++ {
+			row[j] = fmt.Sprintf("%d", arcs[i][j])
+		}
+		rep[i] = row
+	}
+	for ; i < 2*n; i++ {
+		row := make([]string, n)
+		for j := 0; j < n; j++ {
+			row[j] = fmt.Sprintf("%d", weights[i-n][j])
+		}
+		rep[i] = row
+	}
+	if hasndd(g.Ndd) {
+		row := make([]string, n)
+		for j := 0; j < n; j++ {
+			row[j] = "0"
+			if g.Ndd[j] {
+				row[j] = "1"
+			}
+		}
+		rep = append(rep, row)
+	}
+
+	return w.WriteAll(rep)
+}
+
+/* The arc list format has tab-separated rows that start with a tag:
+ * first "n" and the number of vertices,
+ * then "a", start, end, capacity and weight for every arc
+ * and "d" and the vertex for every non-directed donor.
+ * Vertices are numbered from 0. */
+func Readarcs(in io.Reader) (Cwdgraph, error) {
+	r := csv.NewReader(in)
+	r.Comma = '\t'
+	r.FieldsPerRecord = -1
+
+	row, err := r.Read()
+	if err != nil {
+		return Cwdgraph{}, err
+	}
+	var n int
+	if len(row) != 2 || row[0] != "n" {
+		return Cwdgraph{}, errors.New("arc list does not start with the number of vertices")
+	}
+	if _, err := fmt.Sscanf(row[1], "%d", &n); err != nil {
+		return Cwdgraph{}, fmt.Errorf("malformed number of vertices: %q", row[1])
+	}
+
+	var arcs []Arc
+	var ndd []bool
+	for row, err = r.Read(); err == nil; row, err = r.Read() {
+		if row[0] == "a" && len(row) == 5 {
+			var arc Arc
+			vals := []*int{&arc.Start, &arc.End, &arc.Cpty, &arc.Wgt}
+			for p, v := range vals {
+				if _, err := fmt.Sscanf(row[p+1], "%d", v); err != nil {
+					return Cwdgraph{}, fmt.Errorf("malformed arc in arc list: %q", row)
+				}
+			}
+			if arc.Start < 0 || arc.Start >= n || arc.End < 0 || arc.End >= n {
+				return Cwdgraph{}, fmt.Errorf("arc with a nonexistent vertex in arc list: %q", row)
+			}
+			if arc.Cpty > 0 && arc.Start != arc.End {
+				arcs = append(arcs, arc)
+			}
+		} else if row[0] == "d" && len(row) == 2 {
+			var d int
+			if _, err := fmt.Sscanf(row[1], "%d", &d); err != nil || d < 0 || d >= n {
+				return Cwdgraph{}, fmt.Errorf("malformed non-directed donor in arc list: %q", row)
+			}
+			if ndd == nil {
+				ndd = make([]bool, n)
+			}
+			ndd[d] = true
+		} else {
+			return Cwdgraph{}, fmt.Errorf("unexpected row in arc list: %q", row)
+		}
+	}
+	if err != io.EOF {
+		return Cwdgraph{}, err
+	}
+	return Newgraph(n, arcs, ndd), nil
+}
+
+/* writes g in the arc list format */
+func Writearcs(out io.Writer, g Cwdgraph) error {
+	w := csv.NewWriter(out)
+	w.Comma = '\t'
+
+	w.Write([]string{"n", fmt.Sprintf("%d", g.N)})
+	for _, arc := range g.Arcs {
+		w.Write([]string{"a",
+			fmt.Sprintf("%d", arc.Start),
+			fmt.Sprintf("%d", arc.End),
+			fmt.Sprintf("%d", arc.Cpty),
+			fmt.Sprintf("%d", arc.Wgt)})
+	}
+	for d, isndd := range g.Ndd {
+		if isndd {
+			w.Write([]string{"d", fmt.Sprintf("%d", d)})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+/* cuiarc and cuivert are for the graph representation
+ * that fits with Cui's data;
+ * each arc is in one from-list and one to-list */
+type cuiarc struct {
+	start int
+	end int
+	cpty int
+}
+type cuivert struct {
+	from []cuiarc
+	to []cuiarc
+}
+
+/* Reads an arc list in the format of Cui's data:
+ * a header line, then one arc per line as
+ * donor id, recipient id, capacity.
+ * If ndds is set, the vertices without incoming arcs are kept
+ * as non-directed donors. The weights are left at zero. */
+func Readcui(in io.Reader, ndds bool) (Cwdgraph, error) {
+	r := csv.NewReader(in)
+	r.Comma = '\t'
+
+	var v []cuivert
+	ids := make(map[int]int)
+
+	var idi int
+	var idj int
+	var val float64
+	_, err := r.Read() // discard column names
+	if err != nil {
+		return Cwdgraph{}, err
+	}
+	row, err := r.Read()
+	for ; err == nil; row, err = r.Read() {
+		_, err1 := fmt.Sscanf(row[0], "%d", &idi)
+		_, err2 := fmt.Sscanf(row[1], "%d", &idj)
+		_, err3 := fmt.Sscanf(row[2], "%g", &val)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return Cwdgraph{}, fmt.Errorf("malformed arc in input: %q", row)
+		}
+
+		i, ok := ids[idi]
+		if !ok {
+			i = len(v)
+			v = append(v, cuivert{})
+			ids[idi] = i
+		}
+		j, ok := ids[idj]
+		if !ok {
+			j = len(v)
+			v = append(v, cuivert{})
+			ids[idj] = j
+		}
+
+		a := cuiarc{i, j, int(val)}
+		v[i].from = append(v[i].from, a)
+		v[j].to = append(v[j].to, a)
+	}
+	if err != io.EOF {
+		return Cwdgraph{}, err
+	}
+
+	return simplifycui(v, ndds), nil
+}
+
+func simplifycui(v []cuivert, ndds bool) Cwdgraph {
+	change := true
+	n := len(v)
+	var vtx cuivert
+
+	isndd := make([]bool, n)
+	if ndds {
+		for i := 0; i < n; i++ {
+			isndd[i] = len(v[i].to) == 0
+		}
+	}
+
+	for change {
+		change = false
+		for i := 0; i < n; i++ {
+			vtx = v[i]
+			af := vtx.from
+			at := vtx.to
+			if len(af) == 0 && len(at) == 0 {
+				continue
+			} else if len(af) == 0 {
+				for _, a := range at {
+					orig := a.start
+					for p, b := range v[orig].from {
+						if b.end == i {
+							v[orig].from = append(v[orig].from[:p], v[orig].from[p+1:]...)
+						}
+					}
+				}
+				v[i].to = nil
+				change = true
+			} else if len(at) == 0 && !isndd[i] {
+				for _, a := range af {
+					dest := a.end
+					for p, b := range v[dest].to {
+						if b.start == i {
+							v[dest].to = append(v[dest].to[:p], v[dest].to[p+1:]...)
+						}
+					}
+				}
+				v[i].from = nil
+				change = true
+			}
+		}
+	}
+
+	new_indices := make([]int, n)
+	var remaining []cuivert
+	var ndd []bool
+	m := 0
+	for i := 0; i < n; i++ {
+		if (len(v[i].to) == 0 && !isndd[i]) || len(v[i].from) == 0 {
+			continue
+		}
+		remaining = append(remaining, v[i])
+		ndd = append(ndd, isndd[i])
+		new_indices[i] = m
+		m++
+	}
+	if !ndds {
+		ndd = nil
+	}
+
+	var arcs []Arc
+	for i := 0; i < m; i++ {
+		for _, a := range remaining[i].from {
+			j := new_indices[a.end]
+			arcs = append(arcs, Arc{i, j, a.cpty, 0})
+		}
+	}
+
+	return Newgraph(m, arcs, ndd)
+}
@@ -0,0 +1,138 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+)
+
+/* the ways the flow through a vertex or along an arc,
+ * a number from 0 up to its capacity, is spread over variables */
+type Intencoding int
+
+const (
+	/* powers of two, the last one cut off (see bitsplit) */
+	Binary Intencoding = iota
+	/* one variable per unit, set from the first one on (thermometer) */
+	Unary
+	/* one variable per value from 0 up, of which exactly one is set */
+	Onehot
+)
+
+/* the values of the variables for a number from 0 up to val */
+func (e Intencoding) split(val int) []int {
+	if val <= 0 {
+		return nil
+	}
+	var vals []int
+	switch e {
+	case Unary:
+		for p := 0; p < val; p++ {
+			vals = append(vals, 1)
+		}
+	case Onehot:
+		for p := 0; p <= val; p++ {
+			vals = append(vals, p)
+		}
+	default:
+		vals = bitsplit(val)
+	}
+	return vals
+}
+
+/* modifies qubomatrix; adds mult times the penalty
+ * for the variables at indices, made by split,
+ * not being a valid encoding: a unary variable that is set
+ * after one that is not, or a one-hot encoding
+ * without exactly one variable set;
+ * nothing if there are no variables, as for a number that can only be 0 */
+func (e Intencoding) addvalidity(qubomatrix *Qubo, indices []int, mult float64) {
+	if len(indices) == 0 {
+		return
+	}
+	switch e {
+	case Unary:
+		for p := 1; p < len(indices); p++ {
+			qubomatrix.Add(indices[p], indices[p], mult)
+			qubomatrix.Add(indices[p-1], indices[p], - mult)
+		}
+	case Onehot:
+		values := make([]int, len(indices))
+		for p := range values {
+			values[p] = 1
+		}
+		addquadpen(qubomatrix, indices, values, -1, mult)
+	}
+}
+
+/* whether the variables at indices hold a valid encoding */
+func (e Intencoding) valid(sol []bool, indices []int) bool {
+	switch e {
+	case Unary:
+		for p := 1; p < len(indices); p++ {
+			if sol[indices[p]] && !sol[indices[p-1]] {
+				return false
+			}
+		}
+	case Onehot:
+		set := 0
+		for _, k := range indices {
+			if sol[k] {
+				set++
+			}
+		}
+		return len(indices) == 0 || set == 1
+	}
+	return true
+}
+
+/* the variables that encode one number together:
+ * runs of entries of tlt for the same vertex or arc (and position) */
+func intgroups(tlt Transltable) [][]int {
+	var groups [][]int
+	for k, v := range tlt {
+		if k > 0 {
+			w := tlt[k-1]
+			w.Bitval = v.Bitval
+			if w == v {
+				last := len(groups) - 1
+				groups[last] = append(groups[last], k)
+				continue
+			}
+		}
+		groups = append(groups, []int{k})
+	}
+	return groups
+}
+
+/* the groups of variables that are not a valid encoding */
+func intbreaks(sol []bool, tlt Transltable, e Intencoding) [][]int {
+	var breaks [][]int
+	if e == Binary {
+		return nil
+	}
+	for _, group := range intgroups(tlt) {
+		if !e.valid(sol, group) {
+			breaks = append(breaks, group)
+		}
+	}
+	return breaks
+}
+
+func showintbreaks(out io.Writer, sol []bool, tlt Transltable, e Intencoding, oldinds []int) {
+	for _, group := range intbreaks(sol, tlt, e) {
+		v := tlt[group[0]]
+		if v.Start == v.End {
+			fmt.Fprintf(out, "Invalid encoding of the flow through vertex %d:", oldinds[v.Start])
+		} else {
+			fmt.Fprintf(out, "Invalid encoding of the flow along %d -> %d:", oldinds[v.Start], oldinds[v.End])
+		}
+		for _, k := range group {
+			if sol[k] {
+				fmt.Fprint(out, " 1")
+			} else {
+				fmt.Fprint(out, " 0")
+			}
+		}
+		fmt.Fprintln(out)
+	}
+}
@@ -0,0 +1,51 @@
+package cycles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+/* The QUBO in spin form, with x_i = (1 + s_i)/2:
+ * the energy is the sum of H[i] s_i over the variables,
+ * of C s_i s_j over the couplings J, and the offset. */
+type Ising struct {
+	H []float64
+	J []Term
+	Offset float64
+}
+
+func Toising(problem Qubo) Ising {
+	ising := Ising{H: make([]float64, problem.N), Offset: problem.Offset}
+	for _, t := range problem.Sorted() {
+		if t.I == t.J {
+			ising.H[t.I] += t.C / 2
+			ising.Offset += t.C / 2
+		} else {
+			ising.J = append(ising.J, Term{t.I, t.J, t.C / 4})
+			ising.H[t.I] += t.C / 4
+			ising.H[t.J] += t.C / 4
+			ising.Offset += t.C / 4
+		}
+	}
+	return ising
+}
+
+/* Writes the Ising model with one item per line:
+ * "n" and the number of variables, "o" and the offset,
+ * "h", i and H[i] for every nonzero H[i],
+ * and "j", i, j and the coupling for every coupling, with i < j. */
+func Writeising(out io.Writer, ising Ising) error {
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "n\t%d\n", len(ising.H))
+	fmt.Fprintf(bw, "o\t%s\n", coef(ising.Offset))
+	for i, h := range ising.H {
+		if h != 0 {
+			fmt.Fprintf(bw, "h\t%d\t%s\n", i, coef(h))
+		}
+	}
+	for _, t := range ising.J {
+		fmt.Fprintf(bw, "j\t%d\t%d\t%s\n", t.I, t.J, coef(t.C))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,126 @@
+package cycles
+
+/* an arc of the residual network;
+ * rev is the index of the opposite arc in the list of to */
+type resarc struct {
+	to int
+	rev int
+	cap int
+	cost int
+}
+
+type resnet [][]resarc
+
+func (net resnet) addarc(from int, to int, cap int, cost int) {
+	net[from] = append(net[from], resarc{to, len(net[to]), cap, cost})
+	net[to] = append(net[to], resarc{from, len(net[from]) - 1, 0, -cost})
+}
+
+/* by how much eps shrinks between refinements */
+const epsfactor = 8
+
+/* Finds a circulation of maximum weight in g.
+ * This solves the same minimum-cost flow problem as Writedimacs writes,
+ * with the weights as negative costs, by cost scaling
+ * (Goldberg and Tarjan): starting from the empty circulation,
+ * a flow on which no residual arc has a reduced cost below -eps
+ * is refined while eps shrinks, by pushing excess along arcs
+ * of negative reduced cost and lowering the price of vertices
+ * that have none. The costs are multiplied by n+1,
+ * so that once eps is 1 the flow is optimal. */
+func Maxcirculation(g Cwdgraph) Flow {
+	n := g.N
+	flow := make(Flow, len(g.Arcs))
+	net := make(resnet, n)
+	/* where every arc of g ends up in the residual network */
+	pos := make([]int, len(g.Arcs))
+	maxcost := 0
+	for k, arc := range g.Arcs {
+		i, j := arc.Start, arc.End
+		pos[k] = -1
+		if arc.Cpty <= 0 || i == j {
+			continue
+		}
+		cost := - arc.Wgt * (n + 1)
+		pos[k] = len(net[i])
+		net.addarc(i, j, arc.Cpty, cost)
+		if cost < -maxcost {
+			maxcost = -cost
+		} else if cost > maxcost {
+			maxcost = cost
+		}
+	}
+
+	price := make([]int, n)
+	excess := make([]int, n)
+	/* the next arc to try pushing along, for every vertex */
+	current := make([]int, n)
+	var queue []int
+
+	push := func(u int, a *resarc, d int) {
+		a.cap -= d
+		net[a.to][a.rev].cap += d
+		excess[u] -= d
+		if excess[a.to] <= 0 && excess[a.to] + d > 0 {
+			queue = append(queue, a.to)
+		}
+		excess[a.to] += d
+	}
+	relabel := func(u int, eps int) {
+		first := true
+		highest := 0
+		for _, a := range net[u] {
+			if a.cap > 0 && (first || price[a.to] - a.cost > highest) {
+				highest = price[a.to] - a.cost
+				first = false
+			}
+		}
+		price[u] = highest - eps
+	}
+
+	for eps := maxcost; eps > 1; {
+		eps /= epsfactor
+		if eps < 1 {
+			eps = 1
+		}
+		/* every arc of negative reduced cost is saturated,
+		 * which leaves excesses and deficits */
+		for u := range net {
+			for e := range net[u] {
+				a := &net[u][e]
+				if a.cap > 0 && a.cost + price[u] - price[a.to] < 0 {
+					push(u, a, a.cap)
+				}
+			}
+			current[u] = 0
+		}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for excess[u] > 0 {
+				if current[u] == len(net[u]) {
+					relabel(u, eps)
+					current[u] = 0
+					continue
+				}
+				a := &net[u][current[u]]
+				if a.cap > 0 && a.cost + price[u] - price[a.to] < 0 {
+					d := excess[u]
+					if a.cap < d {
+						d = a.cap
+					}
+					push(u, a, d)
+				} else {
+					current[u]++
+				}
+			}
+		}
+	}
+
+	for k, arc := range g.Arcs {
+		if pos[k] >= 0 {
+			flow[k] = arc.Cpty - net[arc.Start][pos[k]].cap
+		}
+	}
+	return flow
+}
@@ -0,0 +1,73 @@
+package cycles
+
+import (
+	"testing"
+)
+
+/* the value of the best circulation of g, by trying every flow;
+ * with vdis, no vertex takes in more than one unit */
+func bestcirculation(g Cwdgraph, vdis bool) int {
+	flow := make(Flow, len(g.Arcs))
+	best := 0
+	for {
+		if Iscirculation(g, flow) {
+			in, _ := inout(g, flow)
+			ok := true
+			for i := range in {
+				if vdis && in[i] > 1 {
+					ok = false
+				}
+			}
+			if value := Solval(g, flow); ok && value > best {
+				best = value
+			}
+		}
+		k := 0
+		for k < len(flow) && flow[k] >= g.Arcs[k].Cpty {
+			flow[k] = 0
+			k++
+		}
+		if k == len(flow) {
+			return best
+		}
+		flow[k]++
+	}
+}
+
+/* The cost scaling finds the best circulation,
+ * also with the arcs or vertices used only once. */
+func TestMaxcirculation(t *testing.T) {
+	variants := []struct {
+		name string
+		adis bool
+		vdis bool
+	}{
+		{"", false, false},
+		{"-a", true, false},
+		{"-v", true, true},
+	}
+	for _, v := range variants {
+		g := testgraph()
+		if v.adis {
+			Setcaps1(g)
+		}
+		var flow Flow
+		if v.vdis {
+			flow = Adis2vdis(g, Maxcirculation(Vdis2adis(g)))
+		} else {
+			flow = Maxcirculation(g)
+		}
+		if !Iscirculation(g, flow) {
+			t.Errorf("%s: %v is not a circulation", v.name, flow)
+			continue
+		}
+		for k, f := range flow {
+			if f < 0 || f > g.Arcs[k].Cpty {
+				t.Errorf("%s: flow %d on arc %d, which has capacity %d", v.name, f, k, g.Arcs[k].Cpty)
+			}
+		}
+		if value, best := Solval(g, flow), bestcirculation(g, v.vdis); value != best {
+			t.Errorf("%s: the circulation has value %d instead of %d", v.name, value, best)
+		}
+	}
+}
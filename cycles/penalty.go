@@ -0,0 +1,137 @@
+package cycles
+
+import (
+	"math"
+	"sort"
+)
+
+/* Divides the vertices into rings by breadth-first search,
+ * ignoring the direction of the arcs;
+ * the penalties of a vertex are scaled by the ring factor
+ * to the power of its ring number.
+ * Returns the ring numbers and the highest ring number. */
+func Mkrings(g Cwdgraph) ([]int, int) {
+	n := g.N
+	rings := make([]int, n)
+	max := 0
+	for s := 0; s < n; s++ {
+		if rings[s] != 0 {
+			continue
+		}
+		rings[s] = 1
+		queue := []int{s}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			if rings[i] > max {
+				max = rings[i]
+			}
+			for _, adj := range [][]int{g.Out[i], g.In[i]} {
+				for _, k := range adj {
+					j := g.Arcs[k].Start + g.Arcs[k].End - i
+					if rings[j] == 0 {
+						rings[j] = rings[i] + 1
+						queue = append(queue, j)
+					}
+				}
+			}
+		}
+	}
+	return rings, max
+}
+
+/* The smallest penalty that every violated constraint has to cost
+ * for all infeasible assignments to have a higher energy
+ * than the best feasible one. The objective is at most W,
+ * the total weight of the arc (or cycle) variables,
+ * and a feasible solution of value LB is found greedily,
+ * so violating constraints gains at most W - LB.
+ * A violated constraint costs at least its multiplier,
+ * as the bit values are integers, so one more than that will do.
+ * Takes a greedy packing, so it is not computed unless asked for. */
+func (enc Encoding) Safebound() float64 {
+	w := float64(0)
+	for _, c := range enc.objective() {
+		if c < 0 {
+			w -= c
+		}
+	}
+	lb := enc.Repairer().greedy()
+	return w - float64(lb) + 1
+}
+
+/* the linear coefficient of every variable in the objective:
+ * minus the weight of the arc (or cycle) it stands for */
+func (enc Encoding) objective() []float64 {
+	g := enc.Graph
+	if enc.Opts.Cycleform {
+		obj := make([]float64, len(enc.Cycles))
+		for c, cycle := range enc.Cycles {
+			obj[c] = - float64(cycleweight(cycle, g))
+		}
+		return obj
+	}
+	obj := make([]float64, len(enc.Tlt))
+	for k, v := range enc.Tlt {
+		if v.Start != v.End && v.Pos >= 0 {
+			obj[k] = - float64(g.Arcs[v.Arc].Wgt * v.Bitval)
+		}
+	}
+	return obj
+}
+
+/* Multiplies the penalties of problem, a QUBO built for enc,
+ * by factor, leaving the objective as it is. */
+func (enc Encoding) scalepenalties(problem *Qubo, factor float64) {
+	obj := enc.objective()
+	for ij, c := range problem.Terms {
+		if ij[0] == ij[1] {
+			problem.Terms[ij] = obj[ij[0]] + factor * (c - obj[ij[0]])
+		} else {
+			problem.Terms[ij] = factor * c
+		}
+	}
+	problem.Offset *= factor
+}
+
+/* the average after repeatedly leaving out outliers
+ * beyond three standard deviations; sorts vals */
+func Adjustedavg(vals []int) float64 {
+	k := len(vals)
+	if k == 0 {
+		return 0
+	}
+	sort.Ints(vals)
+	v := make([]float64, k)
+	for i := 0; i < k; i++ {
+		v[i] = float64(vals[i])
+	}
+	var kicked = true
+	var avg float64
+	for kicked {
+		kicked = false
+		sum := float64(0)
+		for i := 0; i < k; i++ {
+			sum += v[i]
+		}
+		avg = sum / float64(k)
+		varsum := float64(0)
+		for i := 0; i < k; i++ {
+			d := v[i] - avg
+			varsum += d*d
+		}
+		variance := varsum / float64(k)
+		stddev := math.Sqrt(variance)
+
+		if v[k-1] > avg + 3*stddev {
+			v = v[:k-1]
+			k--
+			kicked = true
+		} else if v[0] < avg - 3*stddev {
+			v = v[1:]
+			k--
+			kicked = true
+		}
+	}
+	return avg
+}
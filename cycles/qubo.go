@@ -0,0 +1,486 @@
+package cycles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"encoding/csv"
+)
+
+/* A QUBO on N variables, stored as its upper triangle:
+ * Terms[[2]int{i, j}] with i <= j is the coefficient of x_i x_j,
+ * so for i < j it is what a symmetric matrix
+ * has at [i][j] and [j][i] together.
+ * The constant Offset is added to the energy x^T Q x. */
+type Qubo struct {
+	N int
+	Terms map[[2]int]float64
+	Offset float64
+}
+
+type Term struct {
+	I int
+	J int
+	C float64
+}
+
+func Newqubo(n int) Qubo {
+	return Qubo{n, make(map[[2]int]float64), 0}
+}
+
+/* adds c to the coefficient of x_i x_j */
+func (q Qubo) Add(i int, j int, c float64) {
+	if i > j {
+		i, j = j, i
+	}
+	q.Terms[[2]int{i, j}] += c
+}
+
+/* the nonzero terms, by row and then by column */
+func (q Qubo) Sorted() []Term {
+	terms := make([]Term, 0, len(q.Terms))
+	for ij, c := range q.Terms {
+		if c != 0 {
+			terms = append(terms, Term{ij[0], ij[1], c})
+		}
+	}
+	sort.Slice(terms, func(p, r int) bool {
+		if terms[p].I != terms[r].I {
+			return terms[p].I < terms[r].I
+		}
+		return terms[p].J < terms[r].J
+	})
+	return terms
+}
+
+/* the linear coefficients, and for every variable the others
+ * it is coupled to, in order, with the combined coefficients */
+func (q Qubo) adjacency() ([]float64, [][]Term) {
+	diag := make([]float64, q.N)
+	adj := make([][]Term, q.N)
+	for _, t := range q.Sorted() {
+		if t.I == t.J {
+			diag[t.I] = t.C
+		} else {
+			adj[t.I] = append(adj[t.I], t)
+			adj[t.J] = append(adj[t.J], Term{t.J, t.I, t.C})
+		}
+	}
+	for i := range adj {
+		sort.Slice(adj[i], func(p, r int) bool { return adj[i][p].J < adj[i][r].J })
+	}
+	return diag, adj
+}
+
+/* a variable stands for bitval units of flow
+ * through vertex start (if start == end)
+ * or along arc (start, end), which has index Arc in the graph;
+ * in the formulation with bounded cycle length,
+ * arc variables also say in which cycles (those whose
+ * lowest vertex is Leader) and at which position (from 1) they are,
+ * and a Pos of -1 marks the slack of the capacity of Arc,
+ * which its copies share */
+type Translentry struct {
+	Start int
+	End int
+	Bitval int
+	Arc int `json:",omitempty"`
+	Leader int `json:",omitempty"`
+	Pos int `json:",omitempty"`
+}
+type Transltable []Translentry
+
+/* Encodes the search for a maximum-weight circulation in g as a QUBO,
+ * with the flows spread over variables as intenc says.
+ * With noslack, there are no variables for the flow through
+ * the vertices: the in-flow has to equal the out-flow directly,
+ * and in vdis mode at most one arc out of a vertex is used.
+ * In adis mode, modifies the capacities of g. */
+func Constructqubo(g Cwdgraph, penmult float64, vdis bool, adis bool, noslack bool, intenc Intencoding, rings []int, ringfactor float64) (Qubo, Transltable) {
+	n := g.N
+
+	vcaps := make([]int, n)
+	if vdis {
+		for i := 0; i < n; i++ {
+			vcaps[i] = 1
+		}
+	} else {
+		vcaps = vertcaps(g)
+	}
+	if adis {
+		Setcaps1(g)
+	}
+
+	/* first the variables for the vertices,
+	 * then those for the arcs */
+	var vartable Transltable
+	vertvars := make([][]int, n)
+	for i := 0; i < n && !noslack; i++ {
+		for _, bv := range intenc.split(vcaps[i]) {
+			vertvars[i] = append(vertvars[i], len(vartable))
+			vartable = append(vartable, Translentry{Start: i, End: i, Bitval: bv})
+		}
+	}
+	vertsize := len(vartable)
+	arcvars := make([][]int, len(g.Arcs))
+	for k, arc := range g.Arcs {
+		for _, bv := range intenc.split(arc.Cpty) {
+			arcvars[k] = append(arcvars[k], len(vartable))
+			vartable = append(vartable, Translentry{Start: arc.Start, End: arc.End, Bitval: bv, Arc: k})
+		}
+	}
+	nvar := len(vartable)
+
+	QUBO := Newqubo(nvar)
+
+	/* first the base objective */
+	for k := vertsize; k < nvar; k++ {
+		arc := vartable[k]
+		QUBO.Add(k, k, - float64(g.Arcs[arc.Arc].Wgt * arc.Bitval))
+	}
+
+	/* now the penalties */
+	for i := 0; i < n && noslack; i++ {
+		localmult := penmult * math.Pow(ringfactor, float64(rings[i]))
+		var indices []int
+		var values []int
+		for _, a := range g.In[i] {
+			for _, k := range arcvars[a] {
+				indices = append(indices, k)
+				values = append(values, vartable[k].Bitval)
+			}
+		}
+		var outs []int
+		for _, a := range g.Out[i] {
+			for _, k := range arcvars[a] {
+				indices = append(indices, k)
+				values = append(values, - vartable[k].Bitval)
+				if vartable[k].Bitval > 0 {
+					outs = append(outs, k)
+				}
+			}
+		}
+		addquadpen(&QUBO, indices, values, 0, localmult)
+		if vdis {
+			addpairpen(QUBO, outs, localmult)
+		}
+	}
+	for _, adj := range [][][]int{g.Out, g.In} {
+		if noslack {
+			break
+		}
+		for i := 0; i < n; i++ {
+			localmult := penmult * math.Pow(ringfactor, float64(rings[i]))
+			var indices []int
+			var values []int
+			for _, k := range vertvars[i] {
+				indices = append(indices, k)
+				values = append(values, vartable[k].Bitval)
+			}
+			for _, a := range adj[i] {
+				for _, k := range arcvars[a] {
+					indices = append(indices, k)
+					values = append(values, - vartable[k].Bitval)
+				}
+			}
+			addquadpen(&QUBO, indices, values, 0, localmult)
+		}
+	}
+	for i := 0; i < n; i++ {
+		localmult := penmult * math.Pow(ringfactor, float64(rings[i]))
+		intenc.addvalidity(&QUBO, vertvars[i], localmult)
+	}
+	for k, arc := range g.Arcs {
+		localmult := penmult * math.Pow(ringfactor, float64(rings[arc.Start]))
+		intenc.addvalidity(&QUBO, arcvars[k], localmult)
+	}
+
+	return QUBO, vartable
+}
+
+/* the number of bits needed to write k */
+func log2(k int) int {
+	exp := 0
+	for k >= ( 1 << exp ) {
+		exp++
+	}
+	return exp
+}
+
+/* the bit values for a number that can go up to val:
+ * powers of two, with the last one cut off so that they add up to val */
+func bitsplit(val int) []int {
+	nbits := log2(val)
+	bits := make([]int, nbits)
+	for p := 0; p < nbits -1; p++ {
+		bits[p] = 1 << p
+	}
+	if nbits > 0 {
+		bits[nbits -1] = val - ( 1 << (nbits -1)) +1
+	}
+	return bits
+}
+
+/* modifies qubomatrix; adds mult times the square of constant
+ * plus the sum of the values of the variables at indices */
+func addquadpen(qubomatrix *Qubo, indices []int, values []int, constant int, mult float64) {
+	k := len(indices)
+	qubomatrix.Offset += mult * float64(constant * constant)
+	for p := 0; p < k; p++ {
+		qubomatrix.Add(indices[p], indices[p], 2 * mult * float64(constant * values[p]))
+	}
+	for p := 0; p < k; p++ {
+		for q := p; q < k; q++ {
+			i := indices[p]
+			j := indices[q]
+			val1 := values[p]
+			val2 := values[q]
+			if p == q {
+				qubomatrix.Add(i, j, mult * float64(val1 * val2))
+			} else {
+				qubomatrix.Add(i, j, 2 * mult * float64(val1 * val2))
+			}
+		}
+	}
+}
+
+/* Translates an assignment to the variables of the QUBO
+ * back into the flows through the n vertices and along the m arcs. */
+func Backtranslate(sol []bool, tlt Transltable, n int, m int) ([]int, Flow) {
+	vertices := make([]int, n)
+	flow := make(Flow, m)
+
+	nvar := len(tlt)
+	for p := 0; p < nvar; p++ {
+		if sol[p] {
+			vrbl := tlt[p]
+			if vrbl.Start == vrbl.End {
+				vertices[vrbl.Start] += vrbl.Bitval
+			} else if vrbl.Pos >= 0 {
+				flow[vrbl.Arc] += vrbl.Bitval
+			}
+		}
+	}
+	return vertices, flow
+}
+
+/* the ways a QUBO can be written */
+type Quboformat int
+
+const (
+	/* a full symmetric matrix */
+	Symmetric Quboformat = iota
+	/* a full matrix with the terms in the upper triangle */
+	Upper
+	/* a list of the nonzero terms */
+	Sparse
+)
+
+func Writequboas(out io.Writer, problem Qubo, format Quboformat) error {
+	switch format {
+	case Upper:
+		return Writeupperqubo(out, problem)
+	case Sparse:
+		return Writesparsequbo(out, problem)
+	}
+	return Writequbo(out, problem)
+}
+
+/* writes the QUBO as a full symmetric matrix,
+ * with every coupling split evenly over [i][j] and [j][i];
+ * the offset is left out */
+func Writequbo(out io.Writer, problem Qubo) error {
+	return writematrix(out, problem, false)
+}
+
+/* a coefficient with as many digits as it takes
+ * to read it back exactly */
+func coef(c float64) string {
+	return strconv.FormatFloat(c, 'g', -1, 64)
+}
+
+/* writes the QUBO as a full matrix with every coupling in [i][j],
+ * where i < j, after a header stating that */
+func Writeupperqubo(out io.Writer, problem Qubo) error {
+	fmt.Fprintln(out, "# upper triangle of a QUBO: the energy is the sum of Q[i][j] x_i x_j over all i <= j")
+	if problem.Offset != 0 {
+		fmt.Fprintf(out, "# plus the offset %s\n", coef(problem.Offset))
+	}
+	return writematrix(out, problem, true)
+}
+
+func writematrix(out io.Writer, problem Qubo, upper bool) error {
+	w := csv.NewWriter(out)
+	w.Comma = '\t'
+
+	n := problem.N
+	diag, adj := problem.adjacency()
+	row := make([]string, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			row[j] = "0"
+		}
+		row[i] = coef(diag[i])
+		for _, t := range adj[i] {
+			if !upper {
+				row[t.J] = coef(t.C / 2)
+			} else if t.J > i {
+				row[t.J] = coef(t.C)
+			}
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+/* writes the QUBO as a line "n", number of variables,
+ * a line "o", offset, if it is not zero,
+ * and a line i, j, coefficient for every nonzero term,
+ * with i <= j and the coefficient of x_i x_j in full */
+func Writesparsequbo(out io.Writer, problem Qubo) error {
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "n\t%d\n", problem.N)
+	if problem.Offset != 0 {
+		fmt.Fprintf(bw, "o\t%s\n", coef(problem.Offset))
+	}
+	for _, t := range problem.Sorted() {
+		fmt.Fprintf(bw, "%d\t%d\t%s\n", t.I, t.J, coef(t.C))
+	}
+	return bw.Flush()
+}
+
+/* reads a QUBO in any of the formats it can be written in;
+ * lines starting with # are skipped */
+func Readqubo(in io.Reader) (Qubo, error) {
+	br := bufio.NewReader(in)
+	start, _ := br.Peek(2)
+	if string(start) == "n\t" {
+		return readsparsequbo(br)
+	}
+
+	r := csv.NewReader(br)
+	r.Comma = '\t'
+	r.Comment = '#'
+	r.ReuseRecord = true
+	var problem Qubo
+	i := 0
+	for ; ; i++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Qubo{}, err
+		}
+		if i == 0 {
+			problem = Newqubo(len(row))
+		}
+		n := problem.N
+		if i >= n {
+			return Qubo{}, fmt.Errorf("the QUBO has more than %d rows", n)
+		}
+		if len(row) != n {
+			return Qubo{}, fmt.Errorf("row %d of the QUBO has %d entries, expected %d", i, len(row), n)
+		}
+		for j := 0; j < n; j++ {
+			var c float64
+			if _, err := fmt.Sscanf(row[j], "%g", &c); err != nil {
+				return Qubo{}, fmt.Errorf("malformed entry in QUBO: %q", row[j])
+			}
+			if c != 0 {
+				problem.Add(i, j, c)
+			}
+		}
+	}
+	if i < problem.N {
+		return Qubo{}, fmt.Errorf("the QUBO has %d rows, expected %d", i, problem.N)
+	}
+	return problem, nil
+}
+
+func readsparsequbo(in *bufio.Reader) (Qubo, error) {
+	var problem Qubo
+	scanner := bufio.NewScanner(in)
+	l := 0
+	for scanner.Scan() {
+		l++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if l == 1 {
+			var n int
+			if len(fields) != 2 {
+				return Qubo{}, fmt.Errorf("line 1 of the QUBO: expected the number of variables")
+			}
+			if _, err := fmt.Sscanf(fields[1], "%d", &n); err != nil || n < 0 {
+				return Qubo{}, fmt.Errorf("line 1 of the QUBO: malformed number of variables %q", fields[1])
+			}
+			problem = Newqubo(n)
+			continue
+		}
+		if fields[0] == "o" && len(fields) == 2 {
+			if _, err := fmt.Sscanf(fields[1], "%g", &problem.Offset); err != nil {
+				return Qubo{}, fmt.Errorf("line %d of the QUBO: malformed offset %q", l, fields[1])
+			}
+			continue
+		}
+		if len(fields) != 3 {
+			return Qubo{}, fmt.Errorf("line %d of the QUBO has %d entries, expected 3", l, len(fields))
+		}
+		var i, j int
+		var c float64
+		_, err1 := fmt.Sscanf(fields[0], "%d", &i)
+		_, err2 := fmt.Sscanf(fields[1], "%d", &j)
+		_, err3 := fmt.Sscanf(fields[2], "%g", &c)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return Qubo{}, fmt.Errorf("line %d of the QUBO is malformed", l)
+		}
+		if i < 0 || j < 0 || i >= problem.N || j >= problem.N {
+			return Qubo{}, fmt.Errorf("line %d of the QUBO: variable out of range", l)
+		}
+		problem.Add(i, j, c)
+	}
+	return problem, scanner.Err()
+}
+
+/* the value of x^T Q x, without the offset,
+ * which is what samplers report */
+func Energy(problem Qubo, x []bool) float64 {
+	diag, adj := problem.adjacency()
+	return adjenergy(diag, adj, x)
+}
+
+func adjenergy(diag []float64, adj [][]Term, x []bool) float64 {
+	e := float64(0)
+	for i := range diag {
+		if !x[i] {
+			continue
+		}
+		e += diag[i]
+		for _, t := range adj[i] {
+			if t.J > i && x[t.J] {
+				e += t.C
+			}
+		}
+	}
+	return e
+}
+
+/* how far apart two energies of problem have to be
+ * to count as different, given the rounding errors in adding them up */
+func tolerance(problem Qubo) float64 {
+	scale := 0.0
+	for _, c := range problem.Terms {
+		scale += math.Abs(c)
+	}
+	return 1e-9 * (scale + 1)
+}
@@ -0,0 +1,140 @@
+package cycles
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testgraph() Cwdgraph {
+	arcs := []Arc{
+		{0, 1, 2, 4},
+		{1, 0, 1, 3},
+		{1, 2, 3, 5},
+		{2, 0, 2, 2},
+		{2, 3, 1, 3},
+		{3, 4, 2, 2},
+		{4, 2, 1, 1},
+		{3, 1, 1, 6},
+		{4, 0, 0, 7},
+	}
+	return Newgraph(5, arcs, nil)
+}
+
+/* the best circulation of the flow formulation of enc,
+ * like Optimum finds it */
+func optflow(enc Encoding) Flow {
+	g := enc.Graph.Copy()
+	if enc.Opts.Adis {
+		Setcaps1(g)
+	}
+	if enc.Opts.Vdis {
+		return Adis2vdis(g, Maxcirculation(Vdis2adis(g)))
+	}
+	return Maxcirculation(g)
+}
+
+/* an assignment of the QUBO of enc that stands for flow */
+func assign(t *testing.T, enc Encoding, flow Flow) []bool {
+	in, _ := inout(enc.Graph, flow)
+	x := make([]bool, enc.Nvar())
+	for _, group := range intgroups(enc.Tlt) {
+		v := enc.Tlt[group[0]]
+		want := flow[v.Arc]
+		if v.Start == v.End {
+			want = in[v.Start]
+		}
+		found := false
+		for mask := 0; mask < 1 << len(group) && !found; mask++ {
+			sum := 0
+			for p, k := range group {
+				x[k] = mask & (1 << p) != 0
+				if x[k] {
+					sum += enc.Tlt[k].Bitval
+				}
+			}
+			found = sum == want && enc.Opts.Intenc.valid(x, group)
+		}
+		if !found {
+			t.Fatalf("no valid assignment of %d to the variables %v", want, group)
+		}
+	}
+	return x
+}
+
+/* The energy of a feasible assignment, offset included,
+ * has to be minus the value of the solution it stands for,
+ * for every way of encoding the flows. */
+func TestFeasibleenergy(t *testing.T) {
+	type variant struct {
+		name string
+		opts Encopts
+	}
+	var variants []variant
+	for _, e := range []Intencoding{Binary, Unary, Onehot} {
+		name := []string{"binary", "unary", "onehot"}[e]
+		variants = append(variants,
+			variant{name, Encopts{Intenc: e}},
+			variant{name + " -a", Encopts{Adis: true, Intenc: e}},
+			variant{name + " -v", Encopts{Vdis: true, Adis: true, Intenc: e}},
+			variant{name + " -a -n", Encopts{Adis: true, Noslack: true, Intenc: e}},
+			variant{name + " -v -n", Encopts{Vdis: true, Adis: true, Noslack: true, Intenc: e}})
+	}
+	g := testgraph()
+	oldinds := []int{0, 1, 2, 3, 4}
+	for _, v := range variants {
+		v.opts.Mult = 1
+		v.opts.Ringf = 1
+		problem, enc := Encode(g, oldinds, v.opts)
+		for _, flow := range []Flow{make(Flow, len(g.Arcs)), optflow(enc)} {
+			x := assign(t, enc, flow)
+			if !enc.Feasible(x) {
+				t.Errorf("%s: the assignment for %v is infeasible", v.name, flow)
+				continue
+			}
+			value := Solval(g, flow)
+			energy := Energy(problem, x) + problem.Offset
+			if math.Abs(energy + float64(value)) > 1e-9 {
+				t.Errorf("%s: the assignment for %v has energy %g instead of %d", v.name, flow, energy, -value)
+			}
+		}
+	}
+}
+
+/* A QUBO reads back as it was written, in every format;
+ * only the sparse list keeps the offset. */
+func TestQuboformats(t *testing.T) {
+	small := Newqubo(3)
+	small.Add(0, 0, 1.0 / 3)
+	small.Add(0, 2, -2.5)
+	small.Add(1, 2, 1e-7)
+	small.Add(2, 2, -4)
+	small.Offset = 0.1
+	encoded, _ := Encode(testgraph(), []int{0, 1, 2, 3, 4}, Encopts{Mult: 1, Ringf: 1, Adis: true})
+	problems := map[string]Qubo{"small": small, "encoded": encoded}
+	formats := map[string]Quboformat{"symmetric": Symmetric, "upper": Upper, "sparse": Sparse}
+	for pname, problem := range problems {
+		for fname, format := range formats {
+			var buf bytes.Buffer
+			if err := Writequboas(&buf, problem, format); err != nil {
+				t.Fatal(err)
+			}
+			read, err := Readqubo(&buf)
+			if err != nil {
+				t.Errorf("%s as %s: %v", pname, fname, err)
+				continue
+			}
+			if read.N != problem.N || !reflect.DeepEqual(read.Sorted(), problem.Sorted()) {
+				t.Errorf("%s as %s: read back %v instead of %v", pname, fname, read.Sorted(), problem.Sorted())
+			}
+			offset := float64(0)
+			if format == Sparse {
+				offset = problem.Offset
+			}
+			if read.Offset != offset {
+				t.Errorf("%s as %s: the offset is %g instead of %g", pname, fname, read.Offset, offset)
+			}
+		}
+	}
+}
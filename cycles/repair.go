@@ -0,0 +1,311 @@
+package cycles
+
+import (
+	"sort"
+)
+
+/* Repairs turn any sample into a feasible solution:
+ * the flow that is not part of a circulation is dropped,
+ * as are cycles that are too long or use something twice,
+ * then short cycles of positive weight are added where they fit,
+ * and finally single cycles are swapped out for better ones. */
+
+/* the length of the cycles that are added
+ * when the encoding does not bound it */
+const repairlen = 3
+
+type Repair struct {
+	/* the cycles, in the simplified graph */
+	Cycles [][]int
+	Flow Flow
+	/* the value of the arcs as they are in the sample */
+	Raw int
+	/* the value after dropping, adding cycles and improving */
+	Kept int
+	Added int
+	Value int
+}
+
+type packcycle struct {
+	verts []int
+	arcs []int
+	wgt int
+}
+
+type Repairer struct {
+	enc Encoding
+	arccap []int
+	/* -1 if only the arcs limit a vertex */
+	vertcap []int
+	/* the cycles that can be added, the best first */
+	candidates []packcycle
+	/* no maximum if zero */
+	maxcycle int
+	maxchain int
+}
+
+func (enc Encoding) Repairer() Repairer {
+	g := enc.Graph
+	r := Repairer{enc: enc}
+	r.arccap = make([]int, len(g.Arcs))
+	for k, arc := range g.Arcs {
+		r.arccap[k] = arc.Cpty
+		if enc.Opts.Adis {
+			r.arccap[k] = 1
+		}
+	}
+	r.vertcap = make([]int, g.N)
+	for i := range r.vertcap {
+		r.vertcap[i] = -1
+		if enc.Opts.Vdis {
+			r.vertcap[i] = 1
+		}
+	}
+
+	r.maxcycle = enc.Opts.Maxlen
+	r.maxchain = enc.Opts.Maxlen
+	if enc.Opts.Chainlen > 0 {
+		r.maxchain = enc.Opts.Chainlen + 1
+	}
+	var cycles [][]int
+	if enc.Opts.Cycleform {
+		cycles = enc.Cycles
+	} else {
+		candlen := r.maxcycle
+		if candlen == 0 {
+			candlen = repairlen
+		}
+		candchain := r.maxchain
+		if candchain == 0 {
+			candchain = candlen
+		}
+		cycles = Enumcycles(g, candlen, candchain)
+	}
+	for _, cycle := range cycles {
+		c := r.mkcycle(cycle)
+		if c.wgt > 0 {
+			r.candidates = append(r.candidates, c)
+		}
+	}
+	sort.SliceStable(r.candidates, func(p, q int) bool {
+		cp, cq := r.candidates[p], r.candidates[q]
+		if cp.wgt != cq.wgt {
+			return cp.wgt > cq.wgt
+		}
+		return len(cp.arcs) < len(cq.arcs)
+	})
+	return r
+}
+
+func (r Repairer) mkcycle(verts []int) packcycle {
+	arcs := cyclearcs(verts, r.enc.Graph)
+	wgt := 0
+	for _, k := range arcs {
+		wgt += r.enc.Graph.Arcs[k].Wgt
+	}
+	return packcycle{verts, arcs, wgt}
+}
+
+/* whether the cycle is short enough for the encoding */
+func (r Repairer) allowed(c packcycle) bool {
+	ndd := r.enc.Graph.Ndd
+	for _, v := range c.verts {
+		if ndd != nil && ndd[v] {
+			return r.maxchain == 0 || len(c.arcs) <= r.maxchain
+		}
+	}
+	return r.maxcycle == 0 || len(c.arcs) <= r.maxcycle
+}
+
+/* the capacities that are left, changed as cycles are packed */
+type packing struct {
+	arccap []int
+	vertcap []int
+	chosen []packcycle
+}
+
+func (r Repairer) newpacking() packing {
+	p := packing{make([]int, len(r.arccap)), make([]int, len(r.vertcap)), nil}
+	copy(p.arccap, r.arccap)
+	copy(p.vertcap, r.vertcap)
+	return p
+}
+
+func (p packing) copy() packing {
+	q := packing{make([]int, len(p.arccap)), make([]int, len(p.vertcap)), make([]packcycle, len(p.chosen))}
+	copy(q.arccap, p.arccap)
+	copy(q.vertcap, p.vertcap)
+	copy(q.chosen, p.chosen)
+	return q
+}
+
+func (p *packing) add(c packcycle) bool {
+	for _, k := range c.arcs {
+		if p.arccap[k] < 1 {
+			return false
+		}
+	}
+	for _, v := range c.verts {
+		if p.vertcap[v] == 0 {
+			return false
+		}
+	}
+	for _, k := range c.arcs {
+		p.arccap[k]--
+	}
+	for _, v := range c.verts {
+		if p.vertcap[v] > 0 {
+			p.vertcap[v]--
+		}
+	}
+	p.chosen = append(p.chosen, c)
+	return true
+}
+
+func (p *packing) remove(q int) {
+	c := p.chosen[q]
+	for _, k := range c.arcs {
+		p.arccap[k]++
+	}
+	for _, v := range c.verts {
+		if p.vertcap[v] >= 0 {
+			p.vertcap[v]++
+		}
+	}
+	p.chosen = append(p.chosen[:q], p.chosen[q+1:]...)
+}
+
+/* adds candidates, the best first, as long as they fit */
+func (r Repairer) fill(p *packing) {
+	for _, c := range r.candidates {
+		for p.add(c) {
+		}
+	}
+}
+
+/* the value of packing the candidates greedily */
+func (r Repairer) greedy() int {
+	p := r.newpacking()
+	r.fill(&p)
+	return p.value()
+}
+
+func (p packing) value() int {
+	sum := 0
+	for _, c := range p.chosen {
+		sum += c.wgt
+	}
+	return sum
+}
+
+func (r Repairer) Repair(sol []bool) Repair {
+	enc := r.enc
+	g := enc.Graph
+	_, sampled := enc.Flows(sol)
+	var rep Repair
+	rep.Raw = Solval(g, sampled)
+
+	/* the cycles in the sample that can be kept, the best first */
+	var kept []packcycle
+	if enc.Opts.Cycleform {
+		for c, cycle := range enc.Cycles {
+			if sol[c] {
+				kept = append(kept, r.mkcycle(cycle))
+			}
+		}
+	} else {
+		kept = r.circulation(sampled)
+	}
+	sort.SliceStable(kept, func(p, q int) bool { return kept[p].wgt > kept[q].wgt })
+	p := r.newpacking()
+	for _, c := range kept {
+		if r.allowed(c) {
+			p.add(c)
+		}
+	}
+	rep.Kept = p.value()
+
+	r.fill(&p)
+	rep.Added = p.value()
+
+	/* swap out one cycle at a time while that helps */
+	for improved := true; improved; {
+		improved = false
+		for q := range p.chosen {
+			try := p.copy()
+			try.remove(q)
+			r.fill(&try)
+			if try.value() > p.value() {
+				p = try
+				improved = true
+				break
+			}
+		}
+	}
+	rep.Value = p.value()
+
+	rep.Flow = make(Flow, len(g.Arcs))
+	for _, c := range p.chosen {
+		rep.Cycles = append(rep.Cycles, c.verts)
+		for _, k := range c.arcs {
+			rep.Flow[k]++
+		}
+	}
+	return rep
+}
+
+/* the heaviest circulation that only uses flow in the sample,
+ * split into cycles */
+func (r Repairer) circulation(sampled Flow) []packcycle {
+	g := r.enc.Graph.Copy()
+	for k := range g.Arcs {
+		g.Arcs[k].Cpty = sampled[k]
+		if r.arccap[k] < sampled[k] {
+			g.Arcs[k].Cpty = r.arccap[k]
+		}
+	}
+	var flow Flow
+	if r.enc.Opts.Vdis {
+		flow = Adis2vdis(g, Maxcirculation(Vdis2adis(g)))
+	} else {
+		flow = Maxcirculation(g)
+	}
+	indices := make([]int, g.N)
+	isndd := make(map[int]bool)
+	for i := range indices {
+		indices[i] = i
+		if g.Ndd != nil && g.Ndd[i] {
+			isndd[i] = true
+		}
+	}
+	var cycles []packcycle
+	for _, c := range Decomp(g, flow, indices) {
+		/* a cycle through several donors is several chains */
+		parts := [][]int{c.Verts}
+		if segments := chainsegments(c.Verts, isndd); segments != nil {
+			parts = parts[:0]
+			for _, seg := range segments {
+				parts = append(parts, seg[:len(seg)-1])
+			}
+		}
+		for _, part := range parts {
+			for f := 0; f < c.Flow; f++ {
+				cycles = append(cycles, r.mkcycle(part))
+			}
+		}
+	}
+	return cycles
+}
+
+/* the cycles of a repaired solution, in terms of the original graph */
+func (enc Encoding) Repairdecomp(rep Repair) []Cycle {
+	var cycles []Cycle
+	for _, cycle := range rep.Cycles {
+		verts := make([]int, len(cycle))
+		for p, v := range cycle {
+			verts[p] = enc.Oldinds[v]
+		}
+		cycles = append(cycles, Cycle{1, verts, false})
+	}
+	return Markchains(cycles, enc.Ndds)
+}
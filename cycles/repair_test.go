@@ -0,0 +1,72 @@
+package cycles
+
+import (
+	"math/rand"
+	"testing"
+)
+
+/* Whatever the sample, the repaired solution is a circulation
+ * within the capacities of the encoding, of the value it states,
+ * with no cycle longer than allowed. */
+func TestRepair(t *testing.T) {
+	variants := map[string]Encopts{
+		"": {},
+		"-a": {Adis: true},
+		"-v": {Vdis: true, Adis: true},
+		"-a -k 3": {Adis: true, Maxlen: 3},
+		"-v -c -k 4": {Vdis: true, Adis: true, Cycleform: true, Maxlen: 4},
+	}
+	g := testgraph()
+	oldinds := []int{0, 1, 2, 3, 4}
+	rng := rand.New(rand.NewSource(1))
+	for name, opts := range variants {
+		opts.Mult = 1
+		opts.Ringf = 1
+		_, enc := Encode(g, oldinds, opts)
+		r := enc.Repairer()
+		caps := enc.Graph.Copy()
+		if opts.Adis {
+			Setcaps1(caps)
+		}
+		opt, exact := enc.Optimum()
+		for s := 0; s < 20; s++ {
+			x := make([]bool, enc.Nvar())
+			if s == 1 {
+				for k := range x {
+					x[k] = true
+				}
+			} else if s > 1 {
+				randomize(x, rng)
+			}
+			rep := r.Repair(x)
+			if !Iscirculation(caps, rep.Flow) {
+				t.Errorf("%s: sample %d is repaired into %v, which is not a circulation", name, s, rep.Flow)
+				continue
+			}
+			for k, f := range rep.Flow {
+				if f > caps.Arcs[k].Cpty {
+					t.Errorf("%s: sample %d is repaired into flow %d on arc %d, which has capacity %d", name, s, f, k, caps.Arcs[k].Cpty)
+				}
+			}
+			if opts.Vdis {
+				in, _ := inout(caps, rep.Flow)
+				for i, f := range in {
+					if f > 1 {
+						t.Errorf("%s: sample %d is repaired into flow %d through vertex %d", name, s, f, i)
+					}
+				}
+			}
+			for _, c := range rep.Cycles {
+				if opts.Maxlen > 0 && len(c) > opts.Maxlen {
+					t.Errorf("%s: sample %d is repaired with the cycle %v, longer than %d", name, s, c, opts.Maxlen)
+				}
+			}
+			if value := Solval(caps, rep.Flow); value != rep.Value || rep.Value < rep.Kept {
+				t.Errorf("%s: sample %d is repaired into a flow of value %d, stated as %d after keeping %d", name, s, value, rep.Value, rep.Kept)
+			}
+			if exact && rep.Value > opt {
+				t.Errorf("%s: sample %d is repaired into value %d, above the optimum %d", name, s, rep.Value, opt)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cycles
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+/* anything that can turn a QUBO into samples */
+type Sampler interface {
+	Sample(problem Qubo) ([]Sample, error)
+}
+
+/* Runs a command that reads the QUBO on standard input
+ * and writes the samples to standard output,
+ * like the sendrecv scripts do. */
+type External struct {
+	Command []string
+	/* no limit if zero */
+	Timeout time.Duration
+	/* where the standard error of the command goes, if not nil */
+	Stderr io.Writer
+	/* how the QUBO is sent */
+	Format Quboformat
+}
+
+func (e External) Sample(problem Qubo) ([]Sample, error) {
+	if len(e.Command) == 0 {
+		return nil, errors.New("no sampler command given")
+	}
+	ctx := context.Background()
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	var in bytes.Buffer
+	if err := Writequboas(&in, problem, e.Format); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Stdin = &in
+	cmd.Stdout = &out
+	cmd.Stderr = e.Stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("sampler %s timed out after %v", e.Command[0], e.Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sampler %s failed: %w", e.Command[0], err)
+	}
+	samples, err := Readsamples(&out, problem.N)
+	if err != nil {
+		return nil, fmt.Errorf("output of sampler %s: %w", e.Command[0], err)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("sampler %s returned no samples", e.Command[0])
+	}
+	return samples, nil
+}
+
+/* the built-in simulated annealing sampler */
+type Annealer struct {
+	Opts Annealopts
+}
+
+func (s Annealer) Sample(problem Qubo) ([]Sample, error) {
+	return Aggregate(Anneal(problem, s.Opts)), nil
+}
+
+type Tabusearch struct {
+	Opts Tabuopts
+}
+
+func (s Tabusearch) Sample(problem Qubo) ([]Sample, error) {
+	return Aggregate(Tabu(problem, s.Opts)), nil
+}
+
+type Tempering struct {
+	Opts Temperopts
+}
+
+func (s Tempering) Sample(problem Qubo) ([]Sample, error) {
+	return Aggregate(Temper(problem, s.Opts)), nil
+}
@@ -0,0 +1,17 @@
+package cycles
+
+import (
+	"testing"
+)
+
+/* Parallel tempering without replicas or inverse temperatures
+ * falls back on the defaults. */
+func TestTemperdefaults(t *testing.T) {
+	problem, _ := Encode(testgraph(), []int{0, 1, 2, 3, 4}, Encopts{Mult: 1, Ringf: 1, Adis: true})
+	for _, betas := range [][]float64{nil, {}} {
+		samples := Temper(problem, Temperopts{Reads: 2, Betas: betas, Sweeps: 1, Rounds: 2})
+		if len(samples) != 2 {
+			t.Errorf("got %d samples instead of 2", len(samples))
+		}
+	}
+}
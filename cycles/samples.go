@@ -0,0 +1,174 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"encoding/csv"
+)
+
+/* an assignment to the variables of a QUBO,
+ * as returned by a sampler */
+type Sample struct {
+	X []bool
+	Energy float64
+	Occurrences int
+}
+
+/* Merges identical assignments, adding up their occurrences,
+ * and sorts the result by energy. */
+func Aggregate(samples []Sample) []Sample {
+	var agg []Sample
+	seen := make(map[string]int)
+	for _, s := range samples {
+		key := bitstring(s.X)
+		if p, ok := seen[key]; ok {
+			agg[p].Occurrences += s.Occurrences
+			continue
+		}
+		seen[key] = len(agg)
+		agg = append(agg, s)
+	}
+	sort.SliceStable(agg, func(p, q int) bool {
+		return agg[p].Energy < agg[q].Energy
+	})
+	return agg
+}
+
+func bitstring(x []bool) string {
+	b := make([]byte, len(x))
+	for i := range x {
+		if x[i] {
+			b[i] = '1'
+		} else {
+			b[i] = '0'
+		}
+	}
+	return string(b)
+}
+
+/* Writes one sample per row: the values of the variables,
+ * then the energy and the number of occurrences,
+ * like an aggregated sample set written by the sendrecv scripts. */
+func Writesamples(out io.Writer, samples []Sample) error {
+	w := csv.NewWriter(out)
+	w.Comma = '\t'
+
+	for _, s := range samples {
+		n := len(s.X)
+		row := make([]string, n+2)
+		for i := 0; i < n; i++ {
+			if s.X[i] {
+				row[i] = "1"
+			} else {
+				row[i] = "0"
+			}
+		}
+		row[n] = fmt.Sprintf("%g", s.Energy)
+		row[n+1] = fmt.Sprintf("%d", s.Occurrences)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+/* Reads samples in the format of the sendrecv scripts:
+ * the values of the nvar variables, which may also be spins,
+ * with -1 standing for 0, then the energy,
+ * which is NaN if it is missing,
+ * and the number of occurrences, which is 1 if it is missing. */
+func Readsamples(in io.Reader, nvar int) ([]Sample, error) {
+	r := csv.NewReader(in)
+	r.Comma = '\t'
+	r.FieldsPerRecord = -1
+
+	var samples []Sample
+	row, err := r.Read()
+	for s := 0; err == nil; row, err = r.Read() {
+		if len(row) < nvar {
+			return nil, fmt.Errorf("sample %d has %d values, expected at least %d", s, len(row), nvar)
+		}
+		x := make([]bool, nvar)
+		for i := 0; i < nvar; i++ {
+			if row[i] == "0" || row[i] == "-1" {
+				x[i] = false
+			} else if row[i] == "1" || row[i] == "+1" {
+				x[i] = true
+			} else {
+				return nil, fmt.Errorf("unexpected value in sample %d (not 1, 0 or -1) at index %d", s, i)
+			}
+		}
+		energy := math.NaN()
+		if len(row) > nvar {
+			if _, err := fmt.Sscanf(row[nvar], "%g", &energy); err != nil {
+				return nil, fmt.Errorf("malformed energy in sample %d: %q", s, row[nvar])
+			}
+		}
+		occ := 1
+		if len(row) > nvar+1 {
+			if _, err := fmt.Sscanf(row[nvar+1], "%d", &occ); err != nil || occ < 0 {
+				return nil, fmt.Errorf("malformed number of occurrences in sample %d: %q", s, row[nvar+1])
+			}
+		}
+		samples = append(samples, Sample{x, energy, occ})
+		s++
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+	return samples, nil
+}
+
+/* Compares the energies reported for the samples with x^T Q x
+ * and returns the indices of those where the difference is more
+ * than rounding in adding up the terms can explain,
+ * or that do not have one value per variable,
+ * which points to the variables being in another order
+ * or to the samples being of another QUBO.
+ * Fills in the energies that are missing, and corrects those
+ * that are the energies of the Ising model without its offset;
+ * also returns how many of those there were. */
+func Checkenergies(problem Qubo, samples []Sample) ([]int, int) {
+	diag, adj := problem.adjacency()
+	/* what the Ising model adds to x^T Q x */
+	offset := Toising(problem).Offset - problem.Offset
+	var mismatches []int
+	shifted := 0
+	for s := range samples {
+		x := samples[s].X
+		if len(x) != problem.N {
+			mismatches = append(mismatches, s)
+			continue
+		}
+		e := float64(0)
+		size := float64(0)
+		for i := range diag {
+			if !x[i] {
+				continue
+			}
+			e += diag[i]
+			size += math.Abs(diag[i])
+			for _, t := range adj[i] {
+				if t.J > i && x[t.J] {
+					e += t.C
+					size += math.Abs(t.C)
+				}
+			}
+		}
+		tolerance := 1e-9 * (size + 1)
+		if math.IsNaN(samples[s].Energy) {
+			samples[s].Energy = e
+		} else if math.Abs(samples[s].Energy - e) <= tolerance {
+			continue
+		} else if math.Abs(samples[s].Energy + offset - e) <= tolerance + 1e-9 * math.Abs(offset) {
+			samples[s].Energy += offset
+			shifted++
+		} else {
+			mismatches = append(mismatches, s)
+		}
+	}
+	return mismatches, shifted
+}
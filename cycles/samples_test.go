@@ -0,0 +1,90 @@
+package cycles
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/* the energy of the Ising model for the spins of x */
+func isingenergy(ising Ising, x []bool) float64 {
+	spin := func(i int) float64 {
+		if x[i] {
+			return 1
+		}
+		return -1
+	}
+	e := ising.Offset
+	for i, h := range ising.H {
+		e += h * spin(i)
+	}
+	for _, t := range ising.J {
+		e += t.C * spin(t.I) * spin(t.J)
+	}
+	return e
+}
+
+/* The Ising model has the energy of the QUBO, offset included,
+ * for every assignment. */
+func TestToising(t *testing.T) {
+	problem, _ := Encode(testgraph(), []int{0, 1, 2, 3, 4}, Encopts{Mult: 1, Ringf: 1, Adis: true})
+	ising := Toising(problem)
+	rng := rand.New(rand.NewSource(1))
+	x := make([]bool, problem.N)
+	for s := 0; s < 50; s++ {
+		if s > 0 {
+			randomize(x, rng)
+		}
+		want := Energy(problem, x) + problem.Offset
+		if e := isingenergy(ising, x); math.Abs(e - want) > 1e-9 * (math.Abs(want) + 1) {
+			t.Errorf("assignment %v has Ising energy %g instead of %g", x, e, want)
+		}
+	}
+}
+
+/* Samples in spins read like those in bits. */
+func TestSpinsamples(t *testing.T) {
+	bits := "0\t1\t1\t-2\t3\n1\t0\t0\n"
+	spins := "-1\t+1\t1\t-2\t3\n1\t-1\t-1\n"
+	want, err := Readsamples(strings.NewReader(bits), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Readsamples(strings.NewReader(spins), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !reflect.DeepEqual(got[0], want[0]) || got[1].Occurrences != 1 || !math.IsNaN(got[1].Energy) || !reflect.DeepEqual(got[1].X, want[1].X) {
+		t.Errorf("read %v instead of %v", got, want)
+	}
+	if _, err := Readsamples(strings.NewReader("0\t2\t1\n"), 3); err == nil {
+		t.Error("a value of 2 is accepted")
+	}
+}
+
+/* Energies of the Ising model without its offset are corrected,
+ * missing ones filled in, and the others compared. */
+func TestCheckenergies(t *testing.T) {
+	problem := Newqubo(2)
+	problem.Add(0, 0, 2)
+	problem.Add(0, 1, -3)
+	problem.Add(1, 1, 1)
+	problem.Offset = 5
+	/* the Ising model adds 2/2 + 1/2 - 3/4 */
+	samples := []Sample{
+		{[]bool{true, true}, 0, 1},
+		{[]bool{true, true}, -0.75, 1},
+		{[]bool{true, false}, math.NaN(), 1},
+		{[]bool{true, false}, 3, 1},
+		{[]bool{true}, 2, 1},
+	}
+	mismatches, shifted := Checkenergies(problem, samples)
+	if !reflect.DeepEqual(mismatches, []int{3, 4}) || shifted != 1 {
+		t.Errorf("got mismatches %v and %d shifted", mismatches, shifted)
+	}
+	if samples[1].Energy != 0 || samples[2].Energy != 2 {
+		t.Errorf("the energies became %g and %g instead of 0 and 2", samples[1].Energy, samples[2].Energy)
+	}
+}
@@ -0,0 +1,129 @@
+package cycles
+
+/* The strongly connected components of g (Tarjan's algorithm),
+ * counting only the arcs with capacity:
+ * the component of every vertex, and the number of components. */
+func Components(g Cwdgraph) ([]int, int) {
+	n := g.N
+	comp := make([]int, n)
+	index := make([]int, n)
+	low := make([]int, n)
+	onstack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	next := 0
+	ncomps := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onstack[v] = true
+		for _, k := range g.Out[v] {
+			if g.Arcs[k].Cpty <= 0 {
+				continue
+			}
+			w := g.Arcs[k].End
+			if index[w] < 0 {
+				visit(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onstack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+		if low[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onstack[w] = false
+				comp[w] = ncomps
+				if w == v {
+					break
+				}
+			}
+			ncomps++
+		}
+	}
+	for v := 0; v < n; v++ {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	return comp, ncomps
+}
+
+/* Sets the capacity of the arcs between different
+ * strongly connected components to zero, as no circulation
+ * can use them. Returns how many arcs that are. */
+func cutcrossing(g Cwdgraph) int {
+	comp, _ := Components(g)
+	cut := 0
+	for k, arc := range g.Arcs {
+		if arc.Cpty > 0 && comp[arc.Start] != comp[arc.End] {
+			g.Arcs[k].Cpty = 0
+			cut++
+		}
+	}
+	return cut
+}
+
+/* Splits g into one graph per strongly connected component
+ * with more than one vertex, leaving out the arcs between them,
+ * which should already have been removed by Simplify.
+ * The second return value maps the vertices of every part
+ * to those of g. */
+func Split(g Cwdgraph) ([]Cwdgraph, [][]int) {
+	comp, ncomps := Components(g)
+	members := make([][]int, ncomps)
+	for v := 0; v < g.N; v++ {
+		members[comp[v]] = append(members[comp[v]], v)
+	}
+	/* the parts in the order of their first vertex */
+	part := make([]int, ncomps)
+	var indices [][]int
+	for v := 0; v < g.N; v++ {
+		c := comp[v]
+		if members[c][0] != v {
+			continue
+		}
+		part[c] = -1
+		if len(members[c]) > 1 {
+			part[c] = len(indices)
+			indices = append(indices, members[c])
+		}
+	}
+
+	newindex := make([]int, g.N)
+	for _, verts := range indices {
+		for p, v := range verts {
+			newindex[v] = p
+		}
+	}
+	arcs := make([][]Arc, len(indices))
+	for _, arc := range g.Arcs {
+		c := comp[arc.Start]
+		if arc.Cpty <= 0 || c != comp[arc.End] || part[c] < 0 {
+			continue
+		}
+		arcs[part[c]] = append(arcs[part[c]], Arc{newindex[arc.Start], newindex[arc.End], arc.Cpty, arc.Wgt})
+	}
+
+	parts := make([]Cwdgraph, len(indices))
+	for p, verts := range indices {
+		var ndd []bool
+		if g.Ndd != nil {
+			ndd = make([]bool, len(verts))
+			for q, v := range verts {
+				ndd[q] = g.Ndd[v]
+			}
+		}
+		parts[p] = Newgraph(len(verts), arcs[p], ndd)
+	}
+	return parts, indices
+}
@@ -0,0 +1,62 @@
+package cycles
+
+import (
+	"reflect"
+	"testing"
+)
+
+/* The components only count the arcs with capacity,
+ * and Split keeps those with more than one vertex. */
+func TestSplit(t *testing.T) {
+	variants := []struct {
+		name string
+		/* the arcs of testgraph that lose their capacity */
+		zero []int
+		ncomps int
+		parts [][]int
+		narcs []int
+	}{
+		{"all arcs", nil, 1, [][]int{{0, 1, 2, 3, 4}}, []int{8}},
+		{"without 2 -> 3", []int{4}, 3, [][]int{{0, 1, 2}}, []int{4}},
+		{"without 1 -> 2 and 3 -> 1", []int{2, 7}, 2, [][]int{{0, 1}, {2, 3, 4}}, []int{2, 3}},
+	}
+	for _, v := range variants {
+		g := testgraph()
+		for _, k := range v.zero {
+			g.Arcs[k].Cpty = 0
+		}
+		comp, ncomps := Components(g)
+		if ncomps != v.ncomps {
+			t.Errorf("%s: %d components instead of %d", v.name, ncomps, v.ncomps)
+		}
+		for _, verts := range v.parts {
+			for _, u := range verts {
+				if comp[u] != comp[verts[0]] {
+					t.Errorf("%s: %d and %d are in different components", v.name, u, verts[0])
+				}
+			}
+		}
+		parts, indices := Split(g)
+		if !reflect.DeepEqual(indices, v.parts) {
+			t.Errorf("%s: split into %v instead of %v", v.name, indices, v.parts)
+			continue
+		}
+		for p, part := range parts {
+			if part.N != len(v.parts[p]) || len(part.Arcs) != v.narcs[p] {
+				t.Errorf("%s: part %d has %d vertices and %d arcs instead of %d and %d", v.name, p, part.N, len(part.Arcs), len(v.parts[p]), v.narcs[p])
+			}
+			for _, arc := range part.Arcs {
+				found := false
+				for _, k := range g.Out[indices[p][arc.Start]] {
+					orig := g.Arcs[k]
+					if orig.End == indices[p][arc.End] && orig.Cpty == arc.Cpty && orig.Wgt == arc.Wgt {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("%s: part %d has an arc %v that is not in the graph", v.name, p, arc)
+				}
+			}
+		}
+	}
+}
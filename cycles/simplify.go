@@ -0,0 +1,162 @@
+package cycles
+
+/* Caps every arc by the capacities of its endpoints,
+ * where the capacity of a vertex is the smaller of its total in-
+ * and out-capacity, and removes the arcs between different
+ * strongly connected components, until nothing changes anymore;
+ * then removes the vertices that are left with capacity zero,
+ * together with the arcs that are left with capacity zero.
+ * Modifies the capacities of g; the second return value
+ * maps the vertices of the new graph to those of g. */
+func Simplify(g Cwdgraph) (Cwdgraph, []int) {
+	return compact(g, capvertices(g))
+}
+
+/* Like Simplify, but also caps every arc (i, j) by the most flow
+ * that can return from j to i along the other arcs,
+ * as no circulation can use the arc for more than that.
+ * This takes a maximum-flow computation per arc. */
+func Simplifyflow(g Cwdgraph) (Cwdgraph, []int) {
+	vcaps := capvertices(g)
+	for tightenarcs(g) > 0 {
+		vcaps = capvertices(g)
+	}
+	return compact(g, vcaps)
+}
+
+/* does the capping of Simplify, returning the vertex capacities */
+func capvertices(g Cwdgraph) []int {
+	a := g.Arcs
+	vcaps := vertcaps(g)
+
+	change := true
+	for change {
+		change = false
+		for k := range a {
+			c := vcaps[a[k].Start]
+			if vcaps[a[k].End] < c {
+				c = vcaps[a[k].End]
+			}
+			if a[k].Cpty > c {
+				a[k].Cpty = c
+			}
+		}
+		for i, c := range vertcaps(g) {
+			if c < vcaps[i] {
+				vcaps[i] = c
+				change = true
+			}
+		}
+		if !change && cutcrossing(g) > 0 {
+			change = true
+		}
+	}
+	return vcaps
+}
+
+/* caps every arc by the flow that can return along the others;
+ * returns the number of arcs that got a lower capacity */
+func tightenarcs(g Cwdgraph) int {
+	lowered := 0
+	for k, arc := range g.Arcs {
+		if arc.Cpty <= 0 {
+			continue
+		}
+		if f := maxflow(g, arc.End, arc.Start, k, arc.Cpty); f < arc.Cpty {
+			g.Arcs[k].Cpty = f
+			lowered++
+		}
+	}
+	return lowered
+}
+
+/* the most flow, up to limit, that can go from s to t in g
+ * without using arc skip; augments along shortest paths */
+func maxflow(g Cwdgraph, s int, t int, skip int, limit int) int {
+	flow := make(Flow, len(g.Arcs))
+	/* the arc a vertex was reached by, and whether forwards */
+	via := make([]int, g.N)
+	forward := make([]bool, g.N)
+	total := 0
+	for total < limit {
+		for v := range via {
+			via[v] = -1
+		}
+		queue := []int{s}
+		for len(queue) > 0 && via[t] < 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, k := range g.Out[u] {
+				v := g.Arcs[k].End
+				if k != skip && v != s && via[v] < 0 && flow[k] < g.Arcs[k].Cpty {
+					via[v], forward[v] = k, true
+					queue = append(queue, v)
+				}
+			}
+			for _, k := range g.In[u] {
+				v := g.Arcs[k].Start
+				if k != skip && v != s && via[v] < 0 && flow[k] > 0 {
+					via[v], forward[v] = k, false
+					queue = append(queue, v)
+				}
+			}
+		}
+		if via[t] < 0 {
+			break
+		}
+		f := limit - total
+		for v := t; v != s; {
+			k := via[v]
+			if forward[v] {
+				f = min(f, g.Arcs[k].Cpty - flow[k])
+				v = g.Arcs[k].Start
+			} else {
+				f = min(f, flow[k])
+				v = g.Arcs[k].End
+			}
+		}
+		for v := t; v != s; {
+			k := via[v]
+			if forward[v] {
+				flow[k] += f
+				v = g.Arcs[k].Start
+			} else {
+				flow[k] -= f
+				v = g.Arcs[k].End
+			}
+		}
+		total += f
+	}
+	return total
+}
+
+/* removes the vertices with capacity zero and the arcs without capacity */
+func compact(g Cwdgraph, vcaps []int) (Cwdgraph, []int) {
+	a := g.Arcs
+	n := g.N
+
+	new_indices := make([]int, n)
+	var old_indices []int
+	for i := 0; i < n; i++ {
+		if vcaps[i] > 0 {
+			new_indices[i] = len(old_indices)
+			old_indices = append(old_indices, i)
+		}
+	}
+	m := len(old_indices)
+
+	var newndd []bool
+	if g.Ndd != nil {
+		newndd = make([]bool, m)
+		for p := 0; p < m; p++ {
+			newndd[p] = g.Ndd[ old_indices[p] ]
+		}
+	}
+	var newarcs []Arc
+	for _, arc := range a {
+		if arc.Cpty > 0 && vcaps[arc.Start] > 0 && vcaps[arc.End] > 0 {
+			newarcs = append(newarcs, Arc{new_indices[arc.Start], new_indices[arc.End], arc.Cpty, arc.Wgt})
+		}
+	}
+	return Newgraph(m, newarcs, newndd), old_indices
+}
@@ -0,0 +1,57 @@
+package cycles
+
+import (
+	"testing"
+)
+
+/* The flow back along the other arcs of testgraph,
+ * which caps an arc in Simplifyflow. */
+func TestMaxflow(t *testing.T) {
+	variants := []struct {
+		s, t, skip, limit int
+		want int
+	}{
+		/* 2 -> 0 -> 1 and 2 -> 3 -> 1 */
+		{2, 1, 2, 5, 3},
+		{2, 1, 2, 2, 2},
+		/* everything to 4 goes through 2 -> 3 */
+		{0, 4, -1, 5, 1},
+		/* 1 -> 2 -> 0, as 3 -> 1 leads back to the source */
+		{1, 0, 1, 5, 2},
+		{3, 2, 5, 5, 1},
+		{3, 2, -1, 5, 2},
+	}
+	g := testgraph()
+	for _, v := range variants {
+		if f := maxflow(g, v.s, v.t, v.skip, v.limit); f != v.want {
+			t.Errorf("from %d to %d without arc %d, up to %d: flow %d instead of %d", v.s, v.t, v.skip, v.limit, f, v.want)
+		}
+	}
+}
+
+/* Tightening the arcs leaves the best circulation as it is. */
+func TestSimplifyflow(t *testing.T) {
+	for _, adis := range []bool{false, true} {
+		g := testgraph()
+		if adis {
+			Setcaps1(g)
+		}
+		best := bestcirculation(g, false)
+		orig := g.Copy()
+		simple, oldinds := Simplifyflow(g)
+		for _, arc := range simple.Arcs {
+			cpty := 0
+			for _, k := range orig.Out[oldinds[arc.Start]] {
+				if orig.Arcs[k].End == oldinds[arc.End] {
+					cpty = orig.Arcs[k].Cpty
+				}
+			}
+			if arc.Cpty > cpty {
+				t.Errorf("adis %v: arc %d -> %d got capacity %d, more than %d", adis, oldinds[arc.Start], oldinds[arc.End], arc.Cpty, cpty)
+			}
+		}
+		if value := Solval(simple, Maxcirculation(simple)); value != best {
+			t.Errorf("adis %v: the best circulation has value %d instead of %d", adis, value, best)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cycles
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+/* a feasible solution found in a set of samples;
+ * X is the first assignment that stands for it */
+type Solution struct {
+	X []bool
+	Value int
+	Energy float64
+	/* the number of reads that gave it */
+	Occurrences int
+	/* the first sample it is in */
+	Index int
+}
+
+/* statistics over the reads, in which every sample
+ * counts as often as it occurred */
+type Readstats struct {
+	Reads int
+	Feasible int
+	/* over the reads whose energy is known */
+	Meanenergy float64
+	Minenergy float64
+	Atminenergy int
+	/* over the feasible reads */
+	Meanvalue float64
+}
+
+/* the value of the solution a sample stands for */
+func (enc Encoding) Value(sol []bool) int {
+	_, arcflows := enc.Flows(sol)
+	return Solval(enc.Graph, arcflows)
+}
+
+/* The energy of a sample, offset included, split into the objective,
+ * which is minus the weight of the arcs (or cycles) that are on,
+ * and the penalties, which are zero if the sample is feasible. */
+type Energyparts struct {
+	Energy float64
+	Objective float64
+	Penalty float64
+}
+
+/* the energy parts of every one of sols */
+func (enc Encoding) Parts(problem Qubo, sols [][]bool) []Energyparts {
+	diag, adj := problem.adjacency()
+	parts := make([]Energyparts, len(sols))
+	for s, sol := range sols {
+		energy := adjenergy(diag, adj, sol) + problem.Offset
+		objective := float64(- enc.Value(sol))
+		penalty := energy - objective
+		/* what is left of penalties that cancel out is rounding */
+		if math.Abs(penalty) < 1e-9 * (math.Abs(energy) + 1) {
+			penalty = 0
+			energy = objective
+		}
+		parts[s] = Energyparts{energy, objective, penalty}
+	}
+	return parts
+}
+
+/* a flow as a map key */
+func flowkey(flow Flow) string {
+	var b []byte
+	for _, f := range flow {
+		b = strconv.AppendInt(b, int64(f), 10)
+		b = append(b, ' ')
+	}
+	return string(b)
+}
+
+/* Scores all feasible samples. Returns the distinct feasible
+ * solutions, the best first, and statistics over all reads. */
+func (enc Encoding) Solutions(samples []Sample) ([]Solution, Readstats) {
+	var solutions []Solution
+	var stats Readstats
+	seen := make(map[string]int)
+	energysum := float64(0)
+	energyreads := 0
+	valuesum := 0
+	stats.Minenergy = math.Inf(1)
+	for s, sample := range samples {
+		occ := sample.Occurrences
+		stats.Reads += occ
+		if !math.IsNaN(sample.Energy) {
+			energysum += float64(occ) * sample.Energy
+			energyreads += occ
+			if sample.Energy < stats.Minenergy {
+				stats.Minenergy = sample.Energy
+				stats.Atminenergy = 0
+			}
+			if sample.Energy == stats.Minenergy {
+				stats.Atminenergy += occ
+			}
+		}
+		if !enc.Feasible(sample.X) {
+			continue
+		}
+		stats.Feasible += occ
+		/* assignments that differ only in the slack variables,
+		 * or in how they encode the same integers, are one solution */
+		_, arcflows := enc.Flows(sample.X)
+		key := flowkey(arcflows)
+		if p, ok := seen[key]; ok {
+			solutions[p].Occurrences += occ
+			valuesum += occ * solutions[p].Value
+			continue
+		}
+		seen[key] = len(solutions)
+		value := Solval(enc.Graph, arcflows)
+		valuesum += occ * value
+		solutions = append(solutions, Solution{sample.X, value, sample.Energy, occ, s})
+	}
+	stats.Meanenergy = math.NaN()
+	if energyreads > 0 {
+		stats.Meanenergy = energysum / float64(energyreads)
+	}
+	if stats.Feasible > 0 {
+		stats.Meanvalue = float64(valuesum) / float64(stats.Feasible)
+	}
+	sort.SliceStable(solutions, func(p, q int) bool {
+		if solutions[p].Value != solutions[q].Value {
+			return solutions[p].Value > solutions[q].Value
+		}
+		return solutions[p].Energy < solutions[q].Energy
+	})
+	return solutions, stats
+}
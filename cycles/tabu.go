@@ -0,0 +1,115 @@
+package cycles
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+/* Settings for tabu search.
+ * A zero Tenure or Iterations is replaced by a default
+ * derived from the number of variables. */
+type Tabuopts struct {
+	/* the number of restarts, each giving one sample */
+	Reads int
+	/* for how many iterations a variable that was flipped
+	 * may not be flipped again */
+	Tenure int
+	/* after how many iterations without finding a better assignment
+	 * a read stops */
+	Iterations int
+	/* the time all reads together may take; no limit if zero */
+	Timeout time.Duration
+	Seed int64
+}
+
+/* Samples the QUBO by tabu search: every read starts
+ * from a random assignment and keeps flipping the variable
+ * that lowers the energy most, or raises it least,
+ * among those that have not been flipped recently,
+ * unless flipping one of those gives the lowest energy yet.
+ * Returns the best assignment of every read, not aggregated;
+ * when the time is up, fewer reads than asked for
+ * (but at least one). */
+func Tabu(problem Qubo, opts Tabuopts) []Sample {
+	n := problem.N
+	diag, adj := problem.adjacency()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	tenure := opts.Tenure
+	if tenure == 0 {
+		tenure = n / 4
+		if tenure > 20 {
+			tenure = 20
+		}
+	}
+	iterations := opts.Iterations
+	if iterations == 0 {
+		iterations = 20 * n
+	}
+	tol := tolerance(problem)
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+	timeup := func() bool {
+		return !deadline.IsZero() && time.Now().After(deadline)
+	}
+
+	var samples []Sample
+	field := make([]float64, n)
+	/* the iteration from which a variable may be flipped again */
+	free := make([]int, n)
+	for r := 0; r < opts.Reads; r++ {
+		if r > 0 && timeup() {
+			break
+		}
+		x := make([]bool, n)
+		randomize(x, rng)
+		for i := range free {
+			free[i] = 0
+		}
+		setfield(field, x, diag, adj)
+		energy := adjenergy(diag, adj, x)
+		best := append([]bool{}, x...)
+		bestenergy := energy
+
+		for it, since := 0, 0; since < iterations; it, since = it+1, since+1 {
+			if it % 1024 == 0 && timeup() {
+				break
+			}
+			choice := -1
+			mindelta := math.Inf(1)
+			ties := 0
+			for i := 0; i < n; i++ {
+				delta := flipdelta(x, field, i)
+				if free[i] > it && energy + delta >= bestenergy - tol {
+					continue
+				}
+				if delta < mindelta {
+					choice, mindelta, ties = i, delta, 1
+				} else if delta == mindelta {
+					/* pick one of the equally good flips at random */
+					ties++
+					if rng.Intn(ties) == 0 {
+						choice = i
+					}
+				}
+			}
+			if choice < 0 {
+				continue
+			}
+
+			flip(x, field, adj, choice)
+			energy += mindelta
+			free[choice] = it + 1 + tenure
+			if energy < bestenergy - tol {
+				bestenergy = energy
+				copy(best, x)
+				since = -1
+			}
+		}
+		samples = append(samples, Sample{best, adjenergy(diag, adj, best), 1})
+	}
+	return samples
+}
@@ -0,0 +1,120 @@
+package cycles
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+/* Settings for parallel tempering.
+ * Without Betas, the replicas get inverse temperatures
+ * spaced geometrically from Betamin to Betamax,
+ * where a zero one is replaced by the default of annealing;
+ * a zero number of Replicas is replaced by defreplicas. */
+type Temperopts struct {
+	/* the number of independent runs, each giving one sample */
+	Reads int
+	Replicas int
+	Betamin float64
+	Betamax float64
+	/* the inverse temperatures of the replicas, from hot to cold */
+	Betas []float64
+	/* the Metropolis sweeps every replica does between swaps,
+	 * and the number of times they try to swap */
+	Sweeps int
+	Rounds int
+	Seed int64
+}
+
+const defreplicas = 16
+
+/* one copy of the system, at one of the temperatures */
+type replica struct {
+	x []bool
+	field []float64
+	energy float64
+}
+
+/* Samples the QUBO by parallel tempering (replica exchange):
+ * replicas at different temperatures do Metropolis sweeps,
+ * each in its own goroutine, and after every few sweeps
+ * neighbouring ones swap their states with the probability
+ * that keeps both in equilibrium, so that states found hot
+ * can cool down. Every read gives the state of the coldest replica
+ * at the end; the samples are not aggregated. */
+func Temper(problem Qubo, opts Temperopts) []Sample {
+	n := problem.N
+	diag, adj := problem.adjacency()
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	betas := opts.Betas
+	if len(betas) == 0 {
+		replicas := opts.Replicas
+		if replicas <= 0 {
+			replicas = defreplicas
+		}
+		hot, cold := betarange(diag, adj)
+		if opts.Betamin > 0 {
+			hot = opts.Betamin
+		}
+		if opts.Betamax > 0 {
+			cold = opts.Betamax
+		}
+		betas = make([]float64, replicas)
+		for k := range betas {
+			t := float64(0)
+			if replicas > 1 {
+				t = float64(k) / float64(replicas - 1)
+			}
+			betas[k] = hot * math.Pow(cold/hot, t)
+		}
+	}
+	nrep := len(betas)
+
+	/* every replica slot keeps its own generator, seeded from rng,
+	 * so the result only depends on the seed */
+	rngs := make([]*rand.Rand, nrep)
+	for k := range rngs {
+		rngs[k] = rand.New(rand.NewSource(rng.Int63()))
+	}
+	reps := make([]*replica, nrep)
+	for k := range reps {
+		reps[k] = &replica{make([]bool, n), make([]float64, n), 0}
+	}
+
+	samples := make([]Sample, opts.Reads)
+	for r := 0; r < opts.Reads; r++ {
+		for _, rep := range reps {
+			randomize(rep.x, rng)
+			setfield(rep.field, rep.x, diag, adj)
+			rep.energy = adjenergy(diag, adj, rep.x)
+		}
+
+		for round := 0; round < opts.Rounds; round++ {
+			var wg sync.WaitGroup
+			for k := range reps {
+				wg.Add(1)
+				go func(k int) {
+					defer wg.Done()
+					rep := reps[k]
+					for s := 0; s < opts.Sweeps; s++ {
+						rep.energy += sweep(rep.x, rep.field, adj, betas[k], rngs[k])
+					}
+				}(k)
+			}
+			wg.Wait()
+
+			for k := 0; k + 1 < nrep; k++ {
+				d := (betas[k+1] - betas[k]) * (reps[k+1].energy - reps[k].energy)
+				if d >= 0 || rng.Float64() < math.Exp(d) {
+					reps[k], reps[k+1] = reps[k+1], reps[k]
+				}
+			}
+		}
+
+		cold := reps[nrep-1]
+		x := append([]bool{}, cold.x...)
+		samples[r] = Sample{x, adjenergy(diag, adj, x), 1}
+	}
+	return samples
+}
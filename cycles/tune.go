@@ -0,0 +1,105 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+/* Settings for searching the penalty multiplier:
+ * the smallest multiplier is wanted for which at least
+ * the fraction Target of the reads is feasible. */
+type Tuneopts struct {
+	Target float64
+	/* the most QUBOs that are sampled */
+	Steps int
+	/* the search stops when the multipliers that are too low
+	 * and high enough differ by less than this factor */
+	Precision float64
+}
+
+/* one multiplier that was tried */
+type Tunestep struct {
+	Mult float64
+	Stats Readstats
+	/* the best feasible value, -1 if there is none */
+	Best int
+}
+
+/* the QUBO for the multiplier that was chosen, with its samples */
+type Tuned struct {
+	Problem Qubo
+	Enc Encoding
+	Samples []Sample
+	Steps []Tunestep
+}
+
+/* Builds and samples the QUBO for g with a varying multiplier,
+ * starting from opts.Mult, by doubling or halving it until
+ * the target is passed, then by bisection (of the logarithm).
+ * Every step is logged to log, if it is not nil. */
+func Tune(g Cwdgraph, oldinds []int, opts Encopts, sampler Sampler, topts Tuneopts, log io.Writer) (Tuned, error) {
+	var tuned Tuned
+	var found bool
+	/* the highest multiplier that is too low
+	 * and the lowest that is high enough, 0 if not known yet */
+	low := float64(0)
+	high := float64(0)
+	mult := opts.Mult
+	for s := 0; s < topts.Steps; s++ {
+		opts.Mult = mult
+		problem, enc := Encode(g, oldinds, opts)
+		samples, err := sampler.Sample(problem)
+		if err != nil {
+			return tuned, err
+		}
+		solutions, stats := enc.Solutions(samples)
+		step := Tunestep{mult, stats, -1}
+		if len(solutions) > 0 {
+			step.Best = solutions[0].Value
+		}
+		tuned.Steps = append(tuned.Steps, step)
+		rate := float64(stats.Feasible) / float64(stats.Reads)
+		if log != nil {
+			fmt.Fprintf(log, "Multiplier %.4g: %d of %d reads feasible (%.1f%%)", mult, stats.Feasible, stats.Reads, 100 * rate)
+			if step.Best >= 0 {
+				fmt.Fprintf(log, ", best value %d", step.Best)
+			}
+			fmt.Fprintln(log)
+		}
+
+		if rate >= topts.Target {
+			high = mult
+			tuned.Problem, tuned.Enc, tuned.Samples = problem, enc, samples
+			found = true
+		} else {
+			low = mult
+		}
+		if low > 0 && high > 0 && high / low < 1 + topts.Precision {
+			break
+		}
+		if high == 0 {
+			mult = low * 2
+		} else if low == 0 {
+			mult = high / 2
+		} else {
+			mult = math.Sqrt(low * high)
+		}
+	}
+	if !found {
+		return tuned, fmt.Errorf("no multiplier up to %.4g gives %.1f%% feasible reads", low, 100 * topts.Target)
+	}
+	return tuned, nil
+}
+
+/* writes the multiplier, the fraction of feasible reads
+ * and the best value (-1 if none) of every step */
+func Writetrajectory(out io.Writer, steps []Tunestep) error {
+	for _, s := range steps {
+		rate := float64(s.Stats.Feasible) / float64(s.Stats.Reads)
+		if _, err := fmt.Fprintf(out, "%.6g\t%.6g\t%d\n", s.Mult, rate, s.Best); err != nil {
+			return err
+		}
+	}
+	return nil
+}
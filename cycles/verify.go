@@ -0,0 +1,97 @@
+package cycles
+
+import (
+	"fmt"
+	"io"
+)
+
+func Solval(g Cwdgraph, flow Flow) int {
+	value := 0
+	for k, f := range flow {
+		if f > 0 {
+			value += f * g.Arcs[k].Wgt
+		}
+	}
+	return value
+}
+
+/* the total flow into and out of every vertex */
+func inout(g Cwdgraph, flow Flow) ([]int, []int) {
+	in := make([]int, g.N)
+	out := make([]int, g.N)
+	for k, f := range flow {
+		out[ g.Arcs[k].Start ] += f
+		in[ g.Arcs[k].End ] += f
+	}
+	return in, out
+}
+
+/* checks that in-flow equals out-flow everywhere
+ * and that there are no loops */
+func Iscirculation(g Cwdgraph, flow Flow) bool {
+	in, out := inout(g, flow)
+	for i := 0; i < g.N; i++ {
+		if in[i] != out[i] {
+			return false
+		}
+	}
+	for k, f := range flow {
+		if f > 0 && g.Arcs[k].Start == g.Arcs[k].End {
+			return false
+		}
+	}
+	return true
+}
+
+/* checks that the in- and out-flow of every vertex
+ * equal the flow through it; without the flows
+ * through the vertices (nil), that they equal each other */
+func Isfeasible(g Cwdgraph, vertices []int, flow Flow) bool {
+	in, out := inout(g, flow)
+	for i := 0; i < g.N; i++ {
+		if vertices == nil && in[i] != out[i] {
+			return false
+		}
+		if vertices != nil && (in[i] != vertices[i] || out[i] != vertices[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/* reports the vertices where Isfeasible fails */
+func Showbreaks(w io.Writer, g Cwdgraph, vertices []int, flow Flow, oldinds []int) {
+	in, out := inout(g, flow)
+	for i := 0; i < g.N; i++ {
+		if vertices == nil && in[i] != out[i] {
+			fmt.Fprintf(w, "Break at vertex %d; %d in simplified graph\n", oldinds[i], i)
+			fmt.Fprintf(w, "In: %d\n", in[i])
+			fmt.Fprintf(w, "Out: %d\n", out[i])
+		}
+		if vertices != nil && (in[i] != vertices[i] || out[i] != vertices[i]) {
+			fmt.Fprintf(w, "Break at vertex %d; %d in simplified graph\n", oldinds[i], i)
+			fmt.Fprintf(w, "In: %d\n", in[i])
+			fmt.Fprintf(w, "Trough: %d\n", vertices[i])
+			fmt.Fprintf(w, "Out: %d\n", out[i])
+		}
+	}
+}
+
+/* the vertices with more than one unit of out-flow */
+func overloaded(g Cwdgraph, flow Flow) []int {
+	_, out := inout(g, flow)
+	var over []int
+	for i := 0; i < g.N; i++ {
+		if out[i] > 1 {
+			over = append(over, i)
+		}
+	}
+	return over
+}
+
+func showoverloaded(w io.Writer, g Cwdgraph, flow Flow, oldinds []int) {
+	_, out := inout(g, flow)
+	for _, i := range overloaded(g, flow) {
+		fmt.Fprintf(w, "Vertex %d (%d in simplified graph) is used %d times\n", oldinds[i], i, out[i])
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/pjvm742/quantum-cycles
+
+go 1.21
@@ -0,0 +1,29 @@
+# Reading the QUBO files written by cyclequbo,
+# which are either a full matrix, possibly after a header
+# of lines starting with #, or, with -s, a sparse list:
+# a line "n<tab>number of variables", possibly a line "o<tab>offset",
+# then a line "i<tab>j<tab>coefficient" for every term, with i <= j;
+# the offset is left out, as the samplers do not need it
+
+import io
+import pandas
+
+def readqubo(f, readdense):
+	text = f.read()
+	if not text.startswith('n\t'):
+		return readdense(io.StringIO(text))
+	lines = text.splitlines()
+	n = int(lines[0].split('\t')[1])
+	# every variable gets a linear term,
+	# so that the samples have a column for each, in order
+	Q = {(i, i): 0.0 for i in range(n)}
+	for line in lines[1:]:
+		if line.strip() == '' or line.startswith('o\t'):
+			continue
+		i, j, c = line.split('\t')
+		Q[(int(i), int(j))] = float(c)
+	return Q
+
+def readmatrix(f):
+	data = pandas.read_csv(f, sep = '\t', header = None, comment = '#')
+	return data.apply(pandas.to_numeric)
@@ -3,9 +3,9 @@
 from dwave.system import LeapHybridSampler
 import pandas
 import sys
+import qubofile
 
-data = pandas.read_csv(sys.stdin, sep = '\t', header = None)
-data = data.apply(pandas.to_numeric)
+data = qubofile.readqubo(sys.stdin, qubofile.readmatrix)
 #print(data)
 
 hybrid = LeapHybridSampler()
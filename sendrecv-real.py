@@ -5,8 +5,9 @@ from dwave.embedding import chain_strength
 import numpy
 import pandas
 import sys
+import qubofile
 
-data = numpy.loadtxt(sys.stdin, delimiter = '\t')
+data = qubofile.readqubo(sys.stdin, lambda f: numpy.loadtxt(f, delimiter = '\t'))
 #print(data)
 
 #def setchains(bqm, embedding):